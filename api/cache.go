@@ -0,0 +1,118 @@
+package api
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEntry is a stored response body with the validators needed to revalidate it
+type CacheEntry struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	StoredAt     time.Time
+	ExpiresAt    time.Time
+}
+
+// Fresh reports whether the entry can be used without asking Spotify
+func (e CacheEntry) Fresh() bool {
+	return time.Now().Before(e.ExpiresAt)
+}
+
+// Cache persists API responses between runs
+type Cache interface {
+	// Get returns the entry stored under key, with ok false when there is none
+	Get(key string) (entry CacheEntry, ok bool, err error)
+	Put(key string, entry CacheEntry) error
+}
+
+// CacheCounters counts how cached requests were served since the client was created
+type CacheCounters struct {
+	Hits        int64 `json:"hits"`        // served from the cache without a request
+	Revalidated int64 `json:"revalidated"` // confirmed unchanged with a 304
+	Misses      int64 `json:"misses"`      // downloaded in full
+}
+
+// cacheCounters is the concurrency-safe version of CacheCounters
+type cacheCounters struct {
+	hits        atomic.Int64
+	revalidated atomic.Int64
+	misses      atomic.Int64
+}
+
+func (c *cacheCounters) snapshot() CacheCounters {
+	return CacheCounters{
+		Hits:        c.hits.Load(),
+		Revalidated: c.revalidated.Load(),
+		Misses:      c.misses.Load(),
+	}
+}
+
+// cacheTTL returns how long a response from rawURL stays fresh, or 0 when
+// it must not be cached. Discographies and search results change quickly,
+// album and track metadata almost never.
+func cacheTTL(rawURL string) time.Duration {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	// Drop the API version prefix
+	if len(parts) > 0 && parts[0] == "v1" {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return 0
+	}
+
+	switch parts[0] {
+	case "search":
+		return 10 * time.Minute
+	case "playlists":
+		return 10 * time.Minute
+	case "albums", "tracks":
+		return 7 * 24 * time.Hour
+	case "artists":
+		switch {
+		case len(parts) == 3 && parts[2] == "albums":
+			return time.Hour
+		case len(parts) == 3 && parts[2] == "top-tracks":
+			return 6 * time.Hour
+		default:
+			return 24 * time.Hour
+		}
+	default:
+		return 0
+	}
+}
+
+// cacheLookup returns the stored entry for key, logging and ignoring cache failures
+func (c *Client) cacheLookup(key string) (CacheEntry, bool) {
+	if c.cache == nil {
+		return CacheEntry{}, false
+	}
+	entry, ok, err := c.cache.Get(key)
+	if err != nil {
+		log.Printf("Error reading response cache: %v", err)
+		return CacheEntry{}, false
+	}
+	return entry, ok
+}
+
+// cacheStore saves entry under key, logging and ignoring cache failures
+func (c *Client) cacheStore(key string, entry CacheEntry) {
+	if c.cache == nil {
+		return
+	}
+	if err := c.cache.Put(key, entry); err != nil {
+		log.Printf("Error writing response cache: %v", err)
+	}
+}
+
+// CacheCounters returns the cache hit and miss counters of this client
+func (c *Client) CacheCounters() CacheCounters {
+	return c.counters.snapshot()
+}
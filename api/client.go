@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultTokenURL  = "https://accounts.spotify.com/api/token"
+	DefaultBaseURL   = "https://api.spotify.com/v1"
+	DefaultUserAgent = "spotwrap-next"
+
+	// maxRetryAfter is the longest Retry-After the client sleeps through
+	// before returning a RateLimitError to the caller
+	maxRetryAfter = 30 * time.Second
+)
+
+// Config holds the settings used to build a Client. Zero values fall back
+// to the public Spotify endpoints and a shared keep-alive transport.
+type Config struct {
+	BaseURL   string
+	TokenURL  string
+	UserAgent string
+	Transport http.RoundTripper
+	Limiter   *rate.Limiter
+
+	// Cache stores responses between runs. Nil disables caching.
+	Cache Cache
+
+	// Credentials supplies the client ID and secret used to request access tokens
+	Credentials CredentialsFunc
+}
+
+// Client talks to the Spotify Web API. It is safe for concurrent use and
+// should be shared so that connections and the rate limit budget are too.
+type Client struct {
+	BaseURL   string
+	TokenURL  string
+	UserAgent string
+
+	http    *http.Client
+	limiter *rate.Limiter
+	tokens  *TokenSource
+
+	cache    Cache
+	counters cacheCounters
+}
+
+// NewClient creates a Client from cfg
+func NewClient(cfg Config) *Client {
+	c := &Client{
+		BaseURL:   strings.TrimRight(cfg.BaseURL, "/"),
+		TokenURL:  cfg.TokenURL,
+		UserAgent: cfg.UserAgent,
+		limiter:   cfg.Limiter,
+		cache:     cfg.Cache,
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = DefaultBaseURL
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = DefaultTokenURL
+	}
+	if c.UserAgent == "" {
+		c.UserAgent = DefaultUserAgent
+	}
+	if c.limiter == nil {
+		// 1 request per second with burst of 2
+		c.limiter = rate.NewLimiter(rate.Every(time.Second), 2)
+	}
+
+	transport := cfg.Transport
+	if transport == nil {
+		transport = newTransport()
+	}
+	c.http = &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}
+
+	credentials := cfg.Credentials
+	if credentials == nil {
+		credentials = func() (string, string, error) { return "", "", nil }
+	}
+	c.tokens = NewTokenSource(c, credentials)
+
+	return c
+}
+
+// Token returns the access token used for API calls, refreshing it if needed
+func (c *Client) Token(ctx context.Context) (string, error) {
+	return c.tokens.Token(ctx)
+}
+
+// ResetToken discards the current access token, for instance after the credentials changed
+func (c *Client) ResetToken() {
+	c.tokens.Reset()
+}
+
+// newTransport returns a keep-alive transport tuned for many small calls to the same host
+func newTransport() http.RoundTripper {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        20,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// endpoint joins a path to the client's base URL
+func (c *Client) endpoint(format string, args ...any) string {
+	return c.BaseURL + fmt.Sprintf(format, args...)
+}
+
+// rebase rewrites absolute links returned by Spotify, such as paging next
+// links, so that they go through the configured base URL
+func (c *Client) rebase(link string) string {
+	if c.BaseURL != DefaultBaseURL && strings.HasPrefix(link, DefaultBaseURL) {
+		return c.BaseURL + strings.TrimPrefix(link, DefaultBaseURL)
+	}
+	return link
+}
+
+// do sends an HTTP request with rate limiting and retries
+func (c *Client) do(req *http.Request, maxRetries int) (*http.Response, error) {
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	var lastErr error
+	for i := 0; i <= maxRetries; i++ {
+		// Wait for rate limiter
+		err := c.limiter.Wait(req.Context())
+		if err != nil {
+			return nil, fmt.Errorf("rate limiter error: %w", err)
+		}
+
+		// Rewind the body if this is a retry
+		if i > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			if ctxErr := req.Context().Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			lastErr = err
+			continue
+		}
+
+		// Check if we hit the rate limit
+		if resp.StatusCode == http.StatusTooManyRequests {
+			retryAfter := parseRetryAfter(resp)
+			resp.Body.Close()
+
+			// Give up rather than block the caller for minutes
+			if i == maxRetries || retryAfter > maxRetryAfter {
+				return nil, &RateLimitError{RetryAfter: retryAfter}
+			}
+
+			if err := sleepContext(req.Context(), retryAfter); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return resp, nil
+	}
+
+	return nil, &NetworkError{Err: fmt.Errorf("request failed after %d retries: %w", maxRetries, lastErr)}
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getJSON makes an authenticated GET request and decodes the body into v.
+// A request rejected with 401 is retried once with a fresh token. When the
+// client has a cache, fresh responses are served from it and stale ones
+// are revalidated with their ETag.
+func (c *Client) getJSON(ctx context.Context, url string, v any) error {
+	var ttl time.Duration
+	if c.cache != nil {
+		ttl = cacheTTL(url)
+	}
+
+	var cached *CacheEntry
+	if ttl > 0 {
+		if entry, ok := c.cacheLookup(url); ok {
+			if entry.Fresh() {
+				c.counters.hits.Add(1)
+				return decodeBody(url, entry.Body, v)
+			}
+			cached = &entry
+		}
+	}
+
+	resp, err := c.getAuthorized(ctx, url, cached)
+	var authErr *AuthError
+	if errors.As(err, &authErr) && authErr.StatusCode == http.StatusUnauthorized {
+		resp, err = c.getAuthorized(ctx, url, cached)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		c.counters.revalidated.Add(1)
+		cached.ExpiresAt = time.Now().Add(ttl)
+		c.cacheStore(url, *cached)
+		return decodeBody(url, cached.Body, v)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &NetworkError{Err: fmt.Errorf("failed to read response from %s: %w", url, err)}
+	}
+	if err := decodeBody(url, body, v); err != nil {
+		return err
+	}
+
+	if ttl > 0 {
+		c.counters.misses.Add(1)
+		now := time.Now()
+		c.cacheStore(url, CacheEntry{
+			Body:         body,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			StoredAt:     now,
+			ExpiresAt:    now.Add(ttl),
+		})
+	}
+
+	return nil
+}
+
+// decodeBody decodes a JSON response body into v
+func decodeBody(url string, body []byte, v any) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// getAuthorized sends a GET request with the current access token and returns
+// the response if it succeeded or, when cached is set, if Spotify confirmed
+// it is unchanged with a 304. A token rejected by Spotify is invalidated.
+func (c *Client) getAuthorized(ctx context.Context, url string, cached *CacheEntry) (*http.Response, error) {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	// Send request with retry
+	resp, err := c.do(req, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK || (cached != nil && resp.StatusCode == http.StatusNotModified) {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.tokens.Invalidate(token)
+	}
+	return nil, newResponseError(resp)
+}
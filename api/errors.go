@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stable error codes shared with the frontend
+const (
+	ErrCodeAuth        = "auth"
+	ErrCodeRateLimited = "rate_limited"
+	ErrCodeNotFound    = "not_found"
+	ErrCodeNetwork     = "network"
+	ErrCodeCancelled   = "cancelled"
+	ErrCodeInvalidLink = "invalid_link"
+	ErrCodeAPI         = "api_error"
+	ErrCodeUnknown     = "unknown"
+)
+
+// APIError is returned when Spotify answers with a non-200 status that has
+// no more specific error type
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("spotify returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("spotify returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// AuthError is returned when Spotify rejects the client credentials or the access token
+type AuthError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *AuthError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("spotify authentication failed: %s", e.Message)
+	}
+	return fmt.Sprintf("spotify authentication failed (status %d): %s", e.StatusCode, e.Message)
+}
+
+// RateLimitError is returned when Spotify keeps answering 429 or asks to
+// wait longer than the client is willing to sleep
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("spotify rate limit reached, retry after %s", e.RetryAfter)
+}
+
+// NotFoundError is returned when the requested object does not exist
+type NotFoundError struct {
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("spotify object not found: %s", e.Message)
+}
+
+// NetworkError is returned when Spotify could not be reached at all
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("could not reach spotify: %v", e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorPayload is what the frontend receives when a binding fails with an api error
+type ErrorPayload struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retryAfter,omitempty"` // seconds, only set for rate_limited
+}
+
+// ErrorCode maps err to one of the stable ErrCode constants
+func ErrorCode(err error) string {
+	var authErr *AuthError
+	var rateErr *RateLimitError
+	var notFoundErr *NotFoundError
+	var networkErr *NetworkError
+	var apiErr *APIError
+	var linkErr *InvalidLinkError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeCancelled
+	case errors.As(err, &authErr):
+		return ErrCodeAuth
+	case errors.As(err, &rateErr):
+		return ErrCodeRateLimited
+	case errors.As(err, &notFoundErr):
+		return ErrCodeNotFound
+	case errors.As(err, &networkErr):
+		return ErrCodeNetwork
+	case errors.As(err, &apiErr):
+		return ErrCodeAPI
+	case errors.As(err, &linkErr):
+		return ErrCodeInvalidLink
+	default:
+		return ErrCodeUnknown
+	}
+}
+
+// NewErrorPayload converts err into the payload sent to the frontend
+func NewErrorPayload(err error) ErrorPayload {
+	payload := ErrorPayload{
+		Code:    ErrorCode(err),
+		Message: err.Error(),
+	}
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		payload.RetryAfter = int(rateErr.RetryAfter.Round(time.Second) / time.Second)
+	}
+	return payload
+}
+
+// spotifyErrorBody matches both the Web API error object and the
+// accounts service error used by the token endpoint
+type spotifyErrorBody struct {
+	Error            json.RawMessage `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+}
+
+// parseRetryAfter reads the Retry-After header, defaulting to one second
+func parseRetryAfter(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Second
+}
+
+// newResponseError builds a typed error from a failed response, reading the
+// message out of Spotify's error JSON when there is one
+func newResponseError(resp *http.Response) error {
+	message := readErrorMessage(resp)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized,
+		resp.StatusCode == http.StatusBadRequest && strings.HasPrefix(message, "invalid_client"):
+		return &AuthError{StatusCode: resp.StatusCode, Message: message}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{RetryAfter: parseRetryAfter(resp)}
+	case resp.StatusCode == http.StatusNotFound:
+		return &NotFoundError{Message: message}
+	default:
+		return &APIError{StatusCode: resp.StatusCode, Message: message}
+	}
+}
+
+// readErrorMessage extracts the human readable message of an error response
+func readErrorMessage(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var parsed spotifyErrorBody
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return string(body)
+	}
+
+	// Web API: {"error": {"status": 401, "message": "..."}}
+	var webErr struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(parsed.Error, &webErr); err == nil && webErr.Message != "" {
+		return webErr.Message
+	}
+
+	// Accounts service: {"error": "invalid_client", "error_description": "..."}
+	var code string
+	if err := json.Unmarshal(parsed.Error, &code); err == nil {
+		if parsed.ErrorDescription != "" {
+			return fmt.Sprintf("%s: %s", code, parsed.ErrorDescription)
+		}
+		return code
+	}
+	return string(body)
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// LinkKind is the type of Spotify object a link points to
+type LinkKind string
+
+const (
+	LinkArtist   LinkKind = "artist"
+	LinkAlbum    LinkKind = "album"
+	LinkTrack    LinkKind = "track"
+	LinkPlaylist LinkKind = "playlist"
+)
+
+// Link is a Spotify link or URI normalized to its kind and ID
+type Link struct {
+	Kind LinkKind `json:"kind"`
+	ID   string   `json:"id"`
+	URL  string   `json:"url"` // canonical https://open.spotify.com/{kind}/{id}
+}
+
+// InvalidLinkError is returned when a link can't be resolved to a Spotify object
+type InvalidLinkError struct {
+	Link   string
+	Reason string
+}
+
+func (e *InvalidLinkError) Error() string {
+	return fmt.Sprintf("invalid spotify link %q: %s", e.Link, e.Reason)
+}
+
+var (
+	// spotifyIDPattern matches base62 Spotify IDs
+	spotifyIDPattern = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+	// openLinkPattern finds an open.spotify.com link in a short link landing page
+	openLinkPattern = regexp.MustCompile(`https://open\.spotify\.com/(?:intl-[a-zA-Z-]+/)?(?:artist|album|track|playlist)/[0-9A-Za-z]{22}`)
+)
+
+// shortLinkHosts are the hosts used by Spotify's share short links
+var shortLinkHosts = map[string]bool{
+	"spotify.link":     true,
+	"spotify.app.link": true,
+}
+
+// ParseLink normalizes a Spotify URI (spotify:track:ID) or open.spotify.com
+// link, including localized (/intl-fr/), embed and legacy user playlist
+// paths and tracking parameters such as ?si=. Short links need a network
+// round trip and are handled by Client.ResolveLink.
+func ParseLink(raw string) (*Link, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, &InvalidLinkError{Link: raw, Reason: "empty link"}
+	}
+
+	var parts []string
+	if strings.HasPrefix(raw, "spotify:") {
+		parts = strings.Split(strings.TrimPrefix(raw, "spotify:"), ":")
+	} else {
+		if !strings.Contains(raw, "://") {
+			raw = "https://" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, &InvalidLinkError{Link: raw, Reason: err.Error()}
+		}
+		host := strings.ToLower(u.Hostname())
+		if host != "open.spotify.com" && host != "play.spotify.com" {
+			return nil, &InvalidLinkError{Link: raw, Reason: "not a Spotify link"}
+		}
+		parts = strings.Split(strings.Trim(u.Path, "/"), "/")
+	}
+
+	// Drop the prefixes that don't change what the link points to
+	if len(parts) > 0 && strings.HasPrefix(parts[0], "intl-") {
+		parts = parts[1:]
+	}
+	if len(parts) > 0 && parts[0] == "embed" {
+		parts = parts[1:]
+	}
+	// Legacy playlists: user/{owner}/playlist/{id}
+	if len(parts) >= 4 && parts[0] == "user" {
+		parts = parts[2:]
+	}
+
+	if len(parts) < 2 {
+		return nil, &InvalidLinkError{Link: raw, Reason: "missing object type or ID"}
+	}
+
+	kind := LinkKind(parts[0])
+	switch kind {
+	case LinkArtist, LinkAlbum, LinkTrack, LinkPlaylist:
+	default:
+		return nil, &InvalidLinkError{Link: raw, Reason: fmt.Sprintf("unsupported object type %q", parts[0])}
+	}
+
+	id := parts[1]
+	if !spotifyIDPattern.MatchString(id) {
+		return nil, &InvalidLinkError{Link: raw, Reason: fmt.Sprintf("malformed ID %q", id)}
+	}
+
+	return &Link{
+		Kind: kind,
+		ID:   id,
+		URL:  fmt.Sprintf("https://open.spotify.com/%s/%s", kind, id),
+	}, nil
+}
+
+// ResolveLink normalizes any link ParseLink understands and also expands
+// spotify.link short links by following their redirects
+func (c *Client) ResolveLink(ctx context.Context, raw string) (*Link, error) {
+	raw = strings.TrimSpace(raw)
+	withScheme := raw
+	if !strings.Contains(raw, "://") {
+		withScheme = "https://" + raw
+	}
+	u, err := url.Parse(withScheme)
+	if strings.HasPrefix(raw, "spotify:") || err != nil || !shortLinkHosts[strings.ToLower(u.Hostname())] {
+		return ParseLink(raw)
+	}
+
+	expanded, err := c.expandShortLink(ctx, withScheme)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLink(expanded)
+}
+
+// expandShortLink follows the redirects of a short link until it lands on
+// open.spotify.com, or finds the target in the landing page
+func (c *Client) expandShortLink(ctx context.Context, shortLink string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", shortLink, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	client := *c.http
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if strings.EqualFold(req.URL.Hostname(), "open.spotify.com") {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", &NetworkError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if location := resp.Header.Get("Location"); location != "" {
+		return location, nil
+	}
+
+	// Some short links answer with a landing page instead of a redirect
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if match := openLinkPattern.Find(body); match != nil {
+		return string(match), nil
+	}
+	return "", &InvalidLinkError{Link: shortLink, Reason: "short link did not lead to a Spotify object"}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		kind LinkKind
+		id   string
+	}{
+		{"track", "https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC", LinkTrack, "4uLU6hMCjMI75M1A2tKUQC"},
+		{"tracking parameter", "https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC?si=0a1b2c3d4e5f6789", LinkTrack, "4uLU6hMCjMI75M1A2tKUQC"},
+		{"album", "https://open.spotify.com/album/4m2880jivSbbyEGAKfITCa", LinkAlbum, "4m2880jivSbbyEGAKfITCa"},
+		{"artist", "https://open.spotify.com/artist/4tZwfgrHOc3mvqYlEYSvVi", LinkArtist, "4tZwfgrHOc3mvqYlEYSvVi"},
+		{"playlist", "https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M", LinkPlaylist, "37i9dQZF1DXcBWIGoYBM5M"},
+		{"localized", "https://open.spotify.com/intl-fr/album/4m2880jivSbbyEGAKfITCa?si=abc", LinkAlbum, "4m2880jivSbbyEGAKfITCa"},
+		{"embed", "https://open.spotify.com/embed/track/4uLU6hMCjMI75M1A2tKUQC", LinkTrack, "4uLU6hMCjMI75M1A2tKUQC"},
+		{"legacy user playlist", "https://open.spotify.com/user/spotify/playlist/37i9dQZF1DXcBWIGoYBM5M", LinkPlaylist, "37i9dQZF1DXcBWIGoYBM5M"},
+		{"play host", "https://play.spotify.com/artist/4tZwfgrHOc3mvqYlEYSvVi", LinkArtist, "4tZwfgrHOc3mvqYlEYSvVi"},
+		{"without scheme", "open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC", LinkTrack, "4uLU6hMCjMI75M1A2tKUQC"},
+		{"surrounding spaces", "  https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC\n", LinkTrack, "4uLU6hMCjMI75M1A2tKUQC"},
+		{"uri", "spotify:track:4uLU6hMCjMI75M1A2tKUQC", LinkTrack, "4uLU6hMCjMI75M1A2tKUQC"},
+		{"legacy user playlist uri", "spotify:user:spotify:playlist:37i9dQZF1DXcBWIGoYBM5M", LinkPlaylist, "37i9dQZF1DXcBWIGoYBM5M"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := ParseLink(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseLink(%q) returned error: %v", tt.raw, err)
+			}
+			want := "https://open.spotify.com/" + string(tt.kind) + "/" + tt.id
+			if link.Kind != tt.kind || link.ID != tt.id || link.URL != want {
+				t.Errorf("ParseLink(%q) = %+v, want kind %s, id %s and url %s", tt.raw, *link, tt.kind, tt.id, want)
+			}
+		})
+	}
+}
+
+func TestParseLinkInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ""},
+		{"blank", "   "},
+		{"other host", "https://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+		{"short link", "https://spotify.link/AbCdEfGhIjK"},
+		{"missing id", "https://open.spotify.com/track/"},
+		{"unsupported type", "https://open.spotify.com/show/4rOoJ6Egrf8K2IrywzwOMk"},
+		{"malformed id", "https://open.spotify.com/track/4uLU6hMCjMI75M1A2"},
+		{"malformed uri", "spotify:track"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := ParseLink(tt.raw)
+			var invalid *InvalidLinkError
+			if !errors.As(err, &invalid) {
+				t.Errorf("ParseLink(%q) = %+v, %v, want an InvalidLinkError", tt.raw, link, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultMarket is used when no market is configured and none can be detected
+const DefaultMarket = "US"
+
+// marketPattern matches ISO 3166-1 alpha-2 country codes
+var marketPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// localePattern extracts the territory of a POSIX or BCP 47 locale such as
+// fr_FR.UTF-8, en-GB or sr-Latn-RS
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(?:[_-][a-zA-Z]{4})?[_-]([a-zA-Z]{2})\b`)
+
+// IsValidMarket reports whether market is an ISO 3166-1 alpha-2 country code
+func IsValidMarket(market string) bool {
+	return marketPattern.MatchString(market)
+}
+
+// DetectMarket guesses the user's market from the locale environment
+// variables, then from the locale of the OS on Windows, where they are
+// usually unset. It falls back to DefaultMarket when no locale has a territory.
+func DetectMarket() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG", "LANGUAGE"} {
+		if market := marketFromLocale(os.Getenv(env)); market != "" {
+			return market
+		}
+	}
+	if market := marketFromLocale(systemLocale()); market != "" {
+		return market
+	}
+	return DefaultMarket
+}
+
+// marketFromLocale returns the country code of locale, or "" if it has none
+func marketFromLocale(locale string) string {
+	match := localePattern.FindStringSubmatch(locale)
+	if match == nil {
+		return ""
+	}
+	market := strings.ToUpper(match[1])
+	if !IsValidMarket(market) {
+		return ""
+	}
+	return market
+}
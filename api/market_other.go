@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package api
+
+// systemLocale returns "", other systems expose their locale through the
+// environment only
+func systemLocale() string {
+	return ""
+}
@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package api
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// localeNameMaxLength is LOCALE_NAME_MAX_LENGTH, in UTF-16 code units
+const localeNameMaxLength = 85
+
+var procGetUserDefaultLocaleName = syscall.NewLazyDLL("kernel32.dll").NewProc("GetUserDefaultLocaleName")
+
+// systemLocale returns the user's default locale name, such as en-GB, or ""
+// if Windows can't tell
+func systemLocale() string {
+	if procGetUserDefaultLocaleName.Find() != nil {
+		return ""
+	}
+	buf := make([]uint16, localeNameMaxLength)
+	n, _, _ := procGetUserDefaultLocaleName.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(buf)
+}
@@ -0,0 +1,231 @@
+package api
+
+// Image is a cover or profile picture returned by Spotify
+type Image struct {
+	URL    string `json:"url"`
+	Height int    `json:"height"`
+	Width  int    `json:"width"`
+}
+
+// ExternalURLs holds the public links of a Spotify object
+type ExternalURLs struct {
+	Spotify string `json:"spotify"`
+}
+
+// Followers holds the follower count of an artist
+type Followers struct {
+	Total int `json:"total"`
+}
+
+// Restrictions explains why content isn't available, for instance "market"
+type Restrictions struct {
+	Reason string `json:"reason"`
+}
+
+// Paging is the envelope Spotify uses for every paginated list
+type Paging[T any] struct {
+	Href     string `json:"href"`
+	Items    []T    `json:"items"`
+	Limit    int    `json:"limit"`
+	Next     string `json:"next"`
+	Offset   int    `json:"offset"`
+	Previous string `json:"previous"`
+	Total    int    `json:"total"`
+}
+
+// SimplifiedArtist is the artist object embedded in albums and tracks
+type SimplifiedArtist struct {
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	URI          string       `json:"uri"`
+	Href         string       `json:"href"`
+	ExternalURLs ExternalURLs `json:"external_urls"`
+}
+
+// Artist is the full artist object returned by /artists/{id}
+type Artist struct {
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	URI          string       `json:"uri"`
+	Href         string       `json:"href"`
+	ExternalURLs ExternalURLs `json:"external_urls"`
+	Genres       []string     `json:"genres"`
+	Images       []Image      `json:"images"`
+	Popularity   int          `json:"popularity"`
+	Followers    Followers    `json:"followers"`
+}
+
+// SimplifiedAlbum is the album object returned in artist discographies and search results
+type SimplifiedAlbum struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	Type                 string             `json:"type"`
+	AlbumType            string             `json:"album_type"`
+	AlbumGroup           string             `json:"album_group,omitempty"`
+	TotalTracks          int                `json:"total_tracks"`
+	ReleaseDate          string             `json:"release_date"`
+	ReleaseDatePrecision string             `json:"release_date_precision"`
+	Images               []Image            `json:"images"`
+	Artists              []SimplifiedArtist `json:"artists"`
+	AvailableMarkets     []string           `json:"available_markets,omitempty"`
+	URI                  string             `json:"uri"`
+	Href                 string             `json:"href"`
+	ExternalURLs         ExternalURLs       `json:"external_urls"`
+}
+
+// Copyright is a copyright statement attached to an album
+type Copyright struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// Album is the full album object returned by /albums/{id}
+type Album struct {
+	ID                   string                  `json:"id"`
+	Name                 string                  `json:"name"`
+	Type                 string                  `json:"type"`
+	AlbumType            string                  `json:"album_type"`
+	TotalTracks          int                     `json:"total_tracks"`
+	ReleaseDate          string                  `json:"release_date"`
+	ReleaseDatePrecision string                  `json:"release_date_precision"`
+	Images               []Image                 `json:"images"`
+	Artists              []SimplifiedArtist      `json:"artists"`
+	AvailableMarkets     []string                `json:"available_markets,omitempty"`
+	URI                  string                  `json:"uri"`
+	Href                 string                  `json:"href"`
+	ExternalURLs         ExternalURLs            `json:"external_urls"`
+	Genres               []string                `json:"genres"`
+	Label                string                  `json:"label"`
+	Popularity           int                     `json:"popularity"`
+	Copyrights           []Copyright             `json:"copyrights"`
+	Tracks               Paging[SimplifiedTrack] `json:"tracks"`
+}
+
+// SimplifiedTrack is the track object returned in album tracklists
+type SimplifiedTrack struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Type         string             `json:"type"`
+	Artists      []SimplifiedArtist `json:"artists"`
+	DiscNumber   int                `json:"disc_number"`
+	TrackNumber  int                `json:"track_number"`
+	DurationMs   int                `json:"duration_ms"`
+	Explicit     bool               `json:"explicit"`
+	PreviewURL   string             `json:"preview_url"`
+	IsLocal      bool               `json:"is_local"`
+	IsPlayable   *bool              `json:"is_playable,omitempty"` // only set when a market was requested
+	Restrictions *Restrictions      `json:"restrictions,omitempty"`
+	URI          string             `json:"uri"`
+	Href         string             `json:"href"`
+	ExternalURLs ExternalURLs       `json:"external_urls"`
+}
+
+// Playable reports whether the track can be streamed in the requested market
+func (t SimplifiedTrack) Playable() bool {
+	return t.IsPlayable == nil || *t.IsPlayable
+}
+
+// Track is the full track object returned by /tracks/{id} and search
+type Track struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Type         string             `json:"type"`
+	Album        SimplifiedAlbum    `json:"album"`
+	Artists      []SimplifiedArtist `json:"artists"`
+	DiscNumber   int                `json:"disc_number"`
+	TrackNumber  int                `json:"track_number"`
+	DurationMs   int                `json:"duration_ms"`
+	Explicit     bool               `json:"explicit"`
+	PreviewURL   string             `json:"preview_url"`
+	Popularity   int                `json:"popularity"`
+	IsLocal      bool               `json:"is_local"`
+	IsPlayable   *bool              `json:"is_playable,omitempty"` // only set when a market was requested
+	Restrictions *Restrictions      `json:"restrictions,omitempty"`
+	URI          string             `json:"uri"`
+	Href         string             `json:"href"`
+	ExternalURLs ExternalURLs       `json:"external_urls"`
+}
+
+// Playable reports whether the track can be streamed in the requested market
+func (t Track) Playable() bool {
+	return t.IsPlayable == nil || *t.IsPlayable
+}
+
+// SearchResult holds the result pages of a search, one per requested type
+type SearchResult struct {
+	Artists *Paging[Artist]          `json:"artists,omitempty"`
+	Albums  *Paging[SimplifiedAlbum] `json:"albums,omitempty"`
+	Tracks  *Paging[Track]           `json:"tracks,omitempty"`
+}
+
+// ArtistDetails groups everything shown on an artist page
+type ArtistDetails struct {
+	Artist    Artist            `json:"artist"`
+	TopTracks []Track           `json:"top_tracks,omitempty"`
+	Albums    []SimplifiedAlbum `json:"albums"`
+}
+
+// AlbumDetails groups an album with its tracklist. UnavailableTracks counts
+// the tracks that can't be streamed in Market.
+type AlbumDetails struct {
+	Album             Album             `json:"album"`
+	Tracks            []SimplifiedTrack `json:"tracks"`
+	Market            string            `json:"market,omitempty"`
+	UnavailableTracks int               `json:"unavailable_tracks"`
+}
+
+// TrackDetails wraps a single track. Available is false when the track
+// can't be streamed in Market.
+type TrackDetails struct {
+	Track     Track  `json:"track"`
+	Market    string `json:"market,omitempty"`
+	Available bool   `json:"available"`
+}
+
+// PlaylistOwner is the user who owns a playlist
+type PlaylistOwner struct {
+	ID           string       `json:"id"`
+	DisplayName  string       `json:"display_name"`
+	Type         string       `json:"type"`
+	URI          string       `json:"uri"`
+	Href         string       `json:"href"`
+	ExternalURLs ExternalURLs `json:"external_urls"`
+}
+
+// PlaylistTrack is an entry of a playlist. Track is nil when the track was
+// removed from Spotify, and local files have IsLocal set and no ID.
+type PlaylistTrack struct {
+	AddedAt     string `json:"added_at"`
+	IsLocal     bool   `json:"is_local"`
+	Track       *Track `json:"track"`
+	Unavailable bool   `json:"unavailable"` // set when the entry can't be fetched or downloaded
+}
+
+// Playlist is the full playlist object returned by /playlists/{id}
+type Playlist struct {
+	ID            string                `json:"id"`
+	Name          string                `json:"name"`
+	Type          string                `json:"type"`
+	Description   string                `json:"description"`
+	Public        bool                  `json:"public"`
+	Collaborative bool                  `json:"collaborative"`
+	SnapshotID    string                `json:"snapshot_id"`
+	Owner         PlaylistOwner         `json:"owner"`
+	Images        []Image               `json:"images"`
+	Followers     Followers             `json:"followers"`
+	URI           string                `json:"uri"`
+	Href          string                `json:"href"`
+	ExternalURLs  ExternalURLs          `json:"external_urls"`
+	Tracks        Paging[PlaylistTrack] `json:"tracks"`
+}
+
+// PlaylistDetails groups a playlist with its full tracklist
+type PlaylistDetails struct {
+	Playlist         Playlist        `json:"playlist"`
+	Tracks           []PlaylistTrack `json:"tracks"`
+	Market           string          `json:"market,omitempty"`
+	LocalCount       int             `json:"local_count"`
+	UnavailableCount int             `json:"unavailable_count"`
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxPages guards against a misbehaving API that never stops returning next links
+const maxPages = 200
+
+// collectPages appends the items of page to the result and keeps following
+// Spotify's next links until the end of the list or until maxItems items
+// have been collected. A maxItems of 0 means no limit.
+func collectPages[T any](ctx context.Context, c *Client, page Paging[T], maxItems int) ([]T, error) {
+	items := page.Items
+	next := page.Next
+
+	for pages := 1; next != ""; pages++ {
+		if maxItems > 0 && len(items) >= maxItems {
+			break
+		}
+		if pages >= maxPages {
+			return nil, fmt.Errorf("stopped paging after %d pages at %s", pages, next)
+		}
+
+		var nextPage Paging[T]
+		if err := c.getJSON(ctx, c.rebase(next), &nextPage); err != nil {
+			return nil, err
+		}
+		items = append(items, nextPage.Items...)
+		next = nextPage.Next
+	}
+
+	if maxItems > 0 && len(items) > maxItems {
+		items = items[:maxItems]
+	}
+	return items, nil
+}
+
+// getAllPages fetches the list at url and every following page
+func getAllPages[T any](ctx context.Context, c *Client, url string, maxItems int) ([]T, error) {
+	var first Paging[T]
+	if err := c.getJSON(ctx, url, &first); err != nil {
+		return nil, err
+	}
+	return collectPages(ctx, c, first, maxItems)
+}
@@ -2,26 +2,14 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"time"
-
-	"golang.org/x/time/rate"
-)
-
-// Global rate limiter: 1 request per second with burst of 2
-var limiter = rate.NewLimiter(rate.Every(time.Second), 2)
-
-const (
-	TokenURL  = "https://accounts.spotify.com/api/token"
-	BaseURL   = "https://api.spotify.com/v1"
-	SearchURL = BaseURL + "/search"
-	ArtistURL = BaseURL + "/artists"
-	AlbumURL  = BaseURL + "/albums"
-	TrackURL  = BaseURL + "/tracks"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 type TokenResponse struct {
@@ -30,49 +18,9 @@ type TokenResponse struct {
 	ExpiresIn   int    `json:"expires_in"`
 }
 
-// makeRequestWithRetry makes an HTTP request with rate limiting and retries
-func makeRequestWithRetry(req *http.Request, maxRetries int) (*http.Response, error) {
-	var lastErr error
-	for i := 0; i <= maxRetries; i++ {
-		// Wait for rate limiter
-		err := limiter.Wait(req.Context())
-		if err != nil {
-			return nil, fmt.Errorf("rate limiter error: %v", err)
-		}
-
-		// Make the request
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-
-		// Check if we hit the rate limit
-		if resp.StatusCode == 429 {
-			if i == maxRetries {
-				return nil, fmt.Errorf("hit rate limit after %d retries", maxRetries)
-			}
-
-			// Get retry-after header, default to 1 seconds if not present
-			retryAfter := 1
-			if s := resp.Header.Get("Retry-After"); s != "" {
-				fmt.Sscanf(s, "%d", &retryAfter)
-			}
-
-			time.Sleep(time.Duration(retryAfter) * time.Second)
-			continue
-		}
-
-		return resp, nil
-	}
-
-	return nil, fmt.Errorf("request failed after %d retries: %v", maxRetries, lastErr)
-}
-
-func GetToken(clientID, clientSecret string) (string, int, error) {
+func (c *Client) GetToken(ctx context.Context, clientID, clientSecret string) (string, int, error) {
 	if clientID == "" || clientSecret == "" {
-		return "", 0, fmt.Errorf("missing Spotify client ID or client secret")
+		return "", 0, &AuthError{Message: "missing Spotify client ID or client secret"}
 	}
 
 	// Prepare request body
@@ -81,19 +29,23 @@ func GetToken(clientID, clientSecret string) (string, int, error) {
 	data.Set("client_id", clientID)
 	data.Set("client_secret", clientSecret)
 
-	req, err := http.NewRequest("POST", TokenURL, bytes.NewBufferString(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.TokenURL, bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		return "", 0, err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Send request with retry
-	resp, err := makeRequestWithRetry(req, 3)
+	resp, err := c.do(req, 3)
 	if err != nil {
 		return "", 0, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, newResponseError(resp)
+	}
+
 	// Decode response
 	var tokenResponse TokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
@@ -106,130 +58,257 @@ func GetToken(clientID, clientSecret string) (string, int, error) {
 	return tokenResponse.AccessToken, tokenResponse.ExpiresIn, nil
 }
 
-func Search(query string, token string) (map[string]any, error) {
-	req, err := http.NewRequest("GET", SearchURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	q := req.URL.Query()
-	q.Add("q", query)
-	q.Add("type", "album,artist,track")
-	req.URL.RawQuery = q.Encode()
-	req.Header.Set("Authorization", "Bearer "+token)
+// SearchOptions narrows down a search request. Zero values fall back to
+// Spotify's defaults, and an empty Types searches albums, artists and tracks.
+type SearchOptions struct {
+	Types           []string `json:"types"`
+	Market          string   `json:"market"`
+	Limit           int      `json:"limit"`
+	Offset          int      `json:"offset"`
+	IncludeExternal string   `json:"include_external"`
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+// Search queries the search endpoint. Use Offset and Limit to page through
+// results and a single entry in Types to search one category at a time.
+func (c *Client) Search(ctx context.Context, query string, opts SearchOptions) (*SearchResult, error) {
+	types := opts.Types
+	if len(types) == 0 {
+		types = []string{"album", "artist", "track"}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	q := url.Values{}
+	q.Set("q", query)
+	q.Set("type", strings.Join(types, ","))
+	if opts.Market != "" {
+		q.Set("market", opts.Market)
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		q.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if opts.IncludeExternal != "" {
+		q.Set("include_external", opts.IncludeExternal)
 	}
 
-	var result map[string]any
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+	var result SearchResult
+	if err := c.getJSON(ctx, c.endpoint("/search?%s", q.Encode()), &result); err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
-	return result, nil
+	return &result, nil
 }
 
-func GetArtistDetails(id string, token string, noTopTracks bool) (map[string]any, error) {
-	artistData := make(map[string]any)
+// Release groups accepted by the artist albums endpoint
+const (
+	ReleaseGroupAlbum       = "album"
+	ReleaseGroupSingle      = "single"
+	ReleaseGroupAppearsOn   = "appears_on"
+	ReleaseGroupCompilation = "compilation"
+)
 
-	// Get basic artist info using ArtistURL
-	basicInfoURL := fmt.Sprintf("%s/%s", ArtistURL, id)
-	basicInfo, err := makeRequest(basicInfoURL, token)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get basic artist info: %v", err)
+// DefaultReleaseGroups is used when no release groups are requested
+var DefaultReleaseGroups = []string{ReleaseGroupAlbum, ReleaseGroupSingle}
+
+// IsValidReleaseGroup reports whether group is accepted by the artist albums endpoint
+func IsValidReleaseGroup(group string) bool {
+	switch group {
+	case ReleaseGroupAlbum, ReleaseGroupSingle, ReleaseGroupAppearsOn, ReleaseGroupCompilation:
+		return true
+	}
+	return false
+}
+
+// GetArtistDetails fetches an artist, its top tracks unless noTopTracks is set,
+// and up to maxAlbums of its releases in groups available in market (0 fetches
+// the whole discography). An empty market falls back to DefaultMarket.
+func (c *Client) GetArtistDetails(ctx context.Context, id string, market string, groups []string, noTopTracks bool, maxAlbums int) (*ArtistDetails, error) {
+	if market == "" {
+		market = DefaultMarket
+	}
+
+	artistData := &ArtistDetails{}
+
+	// Get basic artist info
+	if err := c.getJSON(ctx, c.endpoint("/artists/%s", id), &artistData.Artist); err != nil {
+		return nil, fmt.Errorf("failed to get basic artist info: %w", err)
 	}
-	artistData["artist"] = basicInfo
 
 	if !noTopTracks {
 		// Get artist's top tracks
-		topTracksURL := fmt.Sprintf("%s/%s/top-tracks?market=US", ArtistURL, id)
-		topTracks, err := makeRequest(topTracksURL, token)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get top tracks: %v", err)
+		var topTracks struct {
+			Tracks []Track `json:"tracks"`
+		}
+		if err := c.getJSON(ctx, c.endpoint("/artists/%s/top-tracks?market=%s", id, market), &topTracks); err != nil {
+			return nil, fmt.Errorf("failed to get top tracks: %w", err)
 		}
-		artistData["top_tracks"] = topTracks["tracks"]
+		artistData.TopTracks = topTracks.Tracks
 	}
 	// Get artist's albums
-	albumsURL := fmt.Sprintf("%s/%s/albums?include_groups=album,single&market=US&limit=10", ArtistURL, id)
-	albums, err := makeRequest(albumsURL, token)
+	albums, err := c.GetArtistAlbums(ctx, id, market, groups, maxAlbums)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get albums: %v", err)
+		return nil, err
 	}
-	artistData["albums"] = albums["items"]
+	artistData.Albums = albums
 
 	return artistData, nil
 }
 
-func GetAlbumDetails(id string, token string) (map[string]any, error) {
-	albumData := make(map[string]any)
+// GetArtistAlbums fetches up to maxAlbums releases of an artist available
+// in market (0 fetches the whole discography). groups selects the release
+// groups to include and defaults to DefaultReleaseGroups.
+func (c *Client) GetArtistAlbums(ctx context.Context, id string, market string, groups []string, maxAlbums int) ([]SimplifiedAlbum, error) {
+	if market == "" {
+		market = DefaultMarket
+	}
+	if len(groups) == 0 {
+		groups = DefaultReleaseGroups
+	}
 
-	// Get basic album info
-	albumURL := fmt.Sprintf("%s/albums/%s", BaseURL, id)
-	albumInfo, err := makeRequest(albumURL, token)
+	albumsURL := c.endpoint("/artists/%s/albums?include_groups=%s&market=%s&limit=50", id, strings.Join(groups, ","), market)
+	albums, err := getAllPages[SimplifiedAlbum](ctx, c, albumsURL, maxAlbums)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get album info: %v", err)
+		return nil, fmt.Errorf("failed to get albums: %w", err)
+	}
+	return albums, nil
+}
+
+// GetLatestReleases fetches an artist and the perGroup newest of its
+// releases in each of groups available in market, newest first. The albums
+// endpoint lists the groups one after another, so a single capped call
+// misses the singles of an artist with many albums.
+func (c *Client) GetLatestReleases(ctx context.Context, id string, market string, groups []string, perGroup int) (*ArtistDetails, error) {
+	if len(groups) == 0 {
+		groups = DefaultReleaseGroups
+	}
+
+	artistData := &ArtistDetails{}
+	if err := c.getJSON(ctx, c.endpoint("/artists/%s", id), &artistData.Artist); err != nil {
+		return nil, fmt.Errorf("failed to get basic artist info: %w", err)
+	}
+
+	for _, group := range groups {
+		albums, err := c.GetArtistAlbums(ctx, id, market, []string{group}, perGroup)
+		if err != nil {
+			return nil, err
+		}
+		artistData.Albums = append(artistData.Albums, albums...)
 	}
-	albumData["album"] = albumInfo
 
-	// Get album tracks
-	tracksURL := fmt.Sprintf("%s/albums/%s/tracks?limit=50", BaseURL, id)
-	tracks, err := makeRequest(tracksURL, token)
+	// Release dates are ISO dates of year, month or day precision, so they
+	// sort as strings
+	slices.SortStableFunc(artistData.Albums, func(a, b SimplifiedAlbum) int {
+		return strings.Compare(b.ReleaseDate, a.ReleaseDate)
+	})
+	return artistData, nil
+}
+
+// maxArtistsPerRequest is the most IDs the several-artists endpoint accepts
+const maxArtistsPerRequest = 50
+
+// GetSeveralArtists fetches many artists with one request per 50 IDs.
+// Unknown IDs are left out of the result.
+func (c *Client) GetSeveralArtists(ctx context.Context, ids []string) ([]Artist, error) {
+	artists := make([]Artist, 0, len(ids))
+
+	for start := 0; start < len(ids); start += maxArtistsPerRequest {
+		end := min(start+maxArtistsPerRequest, len(ids))
+
+		var batch struct {
+			Artists []*Artist `json:"artists"`
+		}
+		batchURL := c.endpoint("/artists?ids=%s", url.QueryEscape(strings.Join(ids[start:end], ",")))
+		if err := c.getJSON(ctx, batchURL, &batch); err != nil {
+			return nil, fmt.Errorf("failed to get artists: %w", err)
+		}
+
+		for _, artist := range batch.Artists {
+			if artist != nil {
+				artists = append(artists, *artist)
+			}
+		}
+	}
+
+	return artists, nil
+}
+
+// GetAlbumDetails fetches an album with every page of its tracks. When market
+// is set, tracks that can't be streamed there are counted in UnavailableTracks.
+func (c *Client) GetAlbumDetails(ctx context.Context, id string, market string) (*AlbumDetails, error) {
+	albumData := &AlbumDetails{Market: market}
+
+	// Get basic album info
+	if err := c.getJSON(ctx, c.endpoint("/albums/%s%s", id, marketQuery("?", market)), &albumData.Album); err != nil {
+		return nil, fmt.Errorf("failed to get album info: %w", err)
+	}
+
+	// The album object embeds the first page of tracks, follow it to the end
+	tracks, err := collectPages(ctx, c, albumData.Album.Tracks, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get album tracks: %v", err)
+		return nil, fmt.Errorf("failed to get album tracks: %w", err)
+	}
+	albumData.Tracks = tracks
+
+	for _, track := range tracks {
+		if !track.Playable() {
+			albumData.UnavailableTracks++
+		}
 	}
-	albumData["tracks"] = tracks["items"]
 
 	return albumData, nil
 }
 
-func GetTrackDetails(id string, token string) (map[string]any, error) {
-	trackData := make(map[string]any)
+// GetTrackDetails fetches a track and whether it can be streamed in market
+func (c *Client) GetTrackDetails(ctx context.Context, id string, market string) (*TrackDetails, error) {
+	trackData := &TrackDetails{Market: market}
 
 	// Get basic track info
-	trackURL := fmt.Sprintf("%s/%s", TrackURL, id)
-	trackInfo, err := makeRequest(trackURL, token)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get track info: %v", err)
+	if err := c.getJSON(ctx, c.endpoint("/tracks/%s%s", id, marketQuery("?", market)), &trackData.Track); err != nil {
+		return nil, fmt.Errorf("failed to get track info: %w", err)
 	}
-	trackData["track"] = trackInfo
+	trackData.Available = trackData.Track.Playable()
 
 	return trackData, nil
 }
 
-// makeRequest makes an API request with rate limiting and retries
-func makeRequest(url string, token string) (map[string]any, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
+// GetPlaylistDetails fetches a playlist with every page of its tracks. Local
+// files, entries that are no longer on Spotify and tracks that can't be
+// streamed in market are kept but flagged.
+func (c *Client) GetPlaylistDetails(ctx context.Context, id string, market string) (*PlaylistDetails, error) {
+	playlistData := &PlaylistDetails{Market: market}
 
-	req.Header.Set("Authorization", "Bearer "+token)
+	// Get basic playlist info
+	if err := c.getJSON(ctx, c.endpoint("/playlists/%s?additional_types=track%s", id, marketQuery("&", market)), &playlistData.Playlist); err != nil {
+		return nil, fmt.Errorf("failed to get playlist info: %w", err)
+	}
 
-	// Send request with retry
-	resp, err := makeRequestWithRetry(req, 3)
+	// The playlist object embeds the first page of tracks, follow it to the end
+	tracks, err := collectPages(ctx, c, playlistData.Playlist.Tracks, 0)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get playlist tracks: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	for i := range tracks {
+		item := &tracks[i]
+		switch {
+		case item.IsLocal:
+			playlistData.LocalCount++
+			item.Unavailable = true
+		case item.Track == nil || item.Track.ID == "" || item.Track.Type != "track" || !item.Track.Playable():
+			playlistData.UnavailableCount++
+			item.Unavailable = true
+		}
 	}
+	playlistData.Tracks = tracks
 
-	var result map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
-	}
+	return playlistData, nil
+}
 
-	return result, nil
+// marketQuery returns the market query parameter prefixed with sep, or "" when market is empty
+func marketQuery(sep string, market string) string {
+	if market == "" {
+		return ""
+	}
+	return sep + "market=" + url.QueryEscape(market)
 }
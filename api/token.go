@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenExpiryMargin is how long before expiry a token gets refreshed
+	tokenExpiryMargin = 5 * time.Minute
+	// tokenRefreshTimeout bounds a refresh shared by several callers
+	tokenRefreshTimeout = 30 * time.Second
+)
+
+// CredentialsFunc returns the client credentials used to request access tokens.
+// It is called on every refresh so that updated credentials are picked up.
+type CredentialsFunc func() (clientID, clientSecret string, err error)
+
+// TokenSource hands out client credentials access tokens. It is safe for
+// concurrent use: a token is refreshed shortly before it expires and
+// concurrent callers share a single refresh.
+type TokenSource struct {
+	client      *Client
+	credentials CredentialsFunc
+
+	mu         sync.Mutex
+	token      string
+	expiry     time.Time
+	inflight   *tokenRefresh
+	generation int // bumped by Reset so stale refreshes are discarded
+}
+
+// tokenRefresh is a refresh in progress, waited on by every caller that needs a token
+type tokenRefresh struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// NewTokenSource creates a TokenSource requesting tokens through client
+func NewTokenSource(client *Client, credentials CredentialsFunc) *TokenSource {
+	return &TokenSource{
+		client:      client,
+		credentials: credentials,
+	}
+}
+
+// Token returns a valid access token, refreshing it first if needed
+func (ts *TokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	if ts.token != "" && time.Until(ts.expiry) > tokenExpiryMargin {
+		token := ts.token
+		ts.mu.Unlock()
+		return token, nil
+	}
+
+	refresh := ts.inflight
+	if refresh == nil {
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		ts.inflight = refresh
+		// The refresh outlives the caller that started it since others may be waiting on it
+		refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenRefreshTimeout)
+		generation := ts.generation
+		go func() {
+			defer cancel()
+			ts.refresh(refreshCtx, refresh, generation)
+		}()
+	}
+	ts.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// refresh requests a new token and publishes it to everyone waiting on r
+func (ts *TokenSource) refresh(ctx context.Context, r *tokenRefresh, generation int) {
+	defer close(r.done)
+
+	var expiresIn int
+	clientID, clientSecret, err := ts.credentials()
+	if err == nil {
+		r.token, expiresIn, err = ts.client.GetToken(ctx, clientID, clientSecret)
+	}
+	r.err = err
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if generation != ts.generation {
+		return
+	}
+	ts.inflight = nil
+	if err == nil {
+		ts.token = r.token
+		ts.expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+}
+
+// Invalidate drops token if it is still the current one, so that the next
+// call to Token fetches a new one. Used when Spotify rejects a token early.
+func (ts *TokenSource) Invalidate(token string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token == token {
+		ts.token = ""
+		ts.expiry = time.Time{}
+	}
+}
+
+// Reset forgets the current token, for instance after the credentials changed
+func (ts *TokenSource) Reset() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.token = ""
+	ts.expiry = time.Time{}
+	ts.inflight = nil
+	ts.generation++
+}
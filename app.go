@@ -2,12 +2,20 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"spotwrap-next/api"
 	"spotwrap-next/database"
 	"spotwrap-next/notifications"
+	"spotwrap-next/spotdl"
 	"spotwrap-next/updater"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -15,12 +23,16 @@ import (
 
 // App represents the main application structure
 type App struct {
-	ctx                 context.Context
-	spotifyAccessToken  string
-	tokenExpirationTime time.Time
-	db                  *database.Database
-	backgroundTicker    *time.Ticker
-	backgroundDone      chan bool
+	ctx              context.Context
+	cancel           context.CancelFunc
+	searchMu         sync.Mutex
+	cancelSearch     context.CancelFunc
+	db               *database.Database
+	spotify          *api.Client
+	downloader       *spotdl.Downloader
+	downloads        spotdl.Controller // changes the downloader's settings
+	backgroundTicker *time.Ticker
+	backgroundCancel context.CancelFunc // stops the background checker
 }
 
 // NewApp creates a new App application struct
@@ -31,95 +43,180 @@ func NewApp() (*App, error) {
 	}
 
 	app := &App{
-		db:             db,
-		backgroundDone: make(chan bool),
+		db:      db,
+		spotify: newSpotifyClient(db),
 	}
 
-	// Start automatic token refresh in a goroutine
-	go app.refreshTokenPeriodically()
-
 	return app, nil
 }
 
-// startup is called when the app starts
-func (a *App) startup(ctx context.Context) {
-	a.ctx = ctx
-	a.fetchSpotifyAccessToken()
+// newSpotifyClient builds the Spotify client, honouring the optional
+// spotify_api_base_url and spotify_token_url settings so the app can be
+// pointed at a caching proxy or a local mock server
+func newSpotifyClient(db *database.Database) *api.Client {
+	cfg := api.Config{
+		Cache: responseCache{db: db},
+		Credentials: func() (string, string, error) {
+			creds, err := db.GetSpotifyCredentials()
+			return creds.ClientID, creds.ClientSecret, err
+		},
+	}
+	if baseURL, err := db.GetSetting("spotify_api_base_url"); err == nil {
+		cfg.BaseURL = baseURL
+	}
+	if tokenURL, err := db.GetSetting("spotify_token_url"); err == nil {
+		cfg.TokenURL = tokenURL
+	}
+	return api.NewClient(cfg)
 }
 
-// fetchSpotifyAccessToken retrieves and updates the Spotify access token if it has expired
-func (a *App) fetchSpotifyAccessToken() {
-	if time.Now().Before(a.tokenExpirationTime) {
-		// Token still valid
-		return
-	}
+// responseCache stores Spotify API responses in the database
+type responseCache struct {
+	db *database.Database
+}
 
-	// Get credentials from database
-	creds, err := a.db.GetSpotifyCredentials()
-	if err != nil {
-		log.Printf("Error fetching credentials: %v", err)
-		return
+func (c responseCache) Get(key string) (api.CacheEntry, bool, error) {
+	r, err := c.db.GetCachedResponse(key)
+	if err != nil || r == nil {
+		return api.CacheEntry{}, false, err
 	}
+	return api.CacheEntry{
+		Body:         r.Body,
+		ETag:         r.ETag,
+		LastModified: r.LastModified,
+		StoredAt:     r.StoredAt,
+		ExpiresAt:    r.ExpiresAt,
+	}, true, nil
+}
 
-	token, expiresIn, err := api.GetToken(creds.ClientID, creds.ClientSecret)
-	if err != nil {
+func (c responseCache) Put(key string, entry api.CacheEntry) error {
+	return c.db.PutCachedResponse(key, database.CachedResponse{
+		Body:         entry.Body,
+		ETag:         entry.ETag,
+		LastModified: entry.LastModified,
+		StoredAt:     entry.StoredAt,
+		ExpiresAt:    entry.ExpiresAt,
+	})
+}
+
+// startup is called when the app starts
+func (a *App) startup(ctx context.Context) {
+	// Every Spotify call derives from this context so that Close can cancel them
+	a.ctx, a.cancel = context.WithCancel(ctx)
+
+	// Warm up the token so the first page load doesn't wait for it
+	if _, err := a.spotify.Token(a.ctx); err != nil {
 		log.Printf("Error fetching token: %v", err)
-		return
 	}
-
-	a.spotifyAccessToken = token
-	a.tokenExpirationTime = time.Now().Add(time.Duration(expiresIn) * time.Second)
 }
 
-// refreshTokenPeriodically refreshes the Spotify token every 55 minutes
-func (a *App) refreshTokenPeriodically() {
-	ticker := time.NewTicker(55 * time.Minute) // Refresh 5 min before expiry
-	defer ticker.Stop()
+// beginSearch cancels the search still in flight, if any, and returns the
+// context for a new one so that superseded searches stop waiting on Spotify
+func (a *App) beginSearch() context.Context {
+	a.searchMu.Lock()
+	defer a.searchMu.Unlock()
 
-	for range ticker.C {
-		a.fetchSpotifyAccessToken()
-		log.Println("Token refreshed")
+	if a.cancelSearch != nil {
+		a.cancelSearch()
 	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelSearch = cancel
+	return ctx
 }
 
-// Search queries the Spotify API with the given query string
-func (a *App) Search(query string) map[string]any {
-	result, err := api.Search(query, a.spotifyAccessToken)
+// Search queries the Spotify API with the given query string and options.
+// Failures are returned as api errors, which the frontend receives as an
+// api.ErrorPayload carrying a stable code.
+func (a *App) Search(query string, opts api.SearchOptions) (*api.SearchResult, error) {
+	if opts.Market == "" {
+		opts.Market = a.market()
+	}
+	result, err := a.spotify.Search(a.beginSearch(), query, opts)
 	if err != nil {
 		log.Printf("Error searching: %v", err)
-		return map[string]any{}
+		return nil, err
 	}
-	return result
+	return result, nil
 }
 
-// GetArtist retrieves artist data from Spotify by ID
-func (a *App) GetArtist(id string) map[string]any {
-	result, err := api.GetArtistDetails(id, a.spotifyAccessToken, false)
+// GetArtist retrieves artist data from Spotify by ID, with its whole discography
+func (a *App) GetArtist(id string) (*api.ArtistDetails, error) {
+	result, err := a.spotify.GetArtistDetails(a.ctx, id, a.market(), a.defaultReleaseGroups(), false, 0)
 	if err != nil {
 		log.Printf("Error getting artist: %v", err)
-		return map[string]any{}
+		return nil, err
+	}
+	return result, nil
+}
+
+// timelineReleasesPerGroup is how many of the newest releases of each release
+// group the Home timeline fetches per artist. It shows the latest 20 overall.
+const timelineReleasesPerGroup = 20
+
+// GetLatestReleases retrieves an artist with the newest releases of the
+// release groups checked for it, newest first, for the Home timeline
+func (a *App) GetLatestReleases(id string) (*api.ArtistDetails, error) {
+	groups := a.defaultReleaseGroups()
+	if artist, err := a.db.GetArtistByID(id); err == nil {
+		groups = a.releaseGroupsFor(*artist)
+	}
+
+	result, err := a.spotify.GetLatestReleases(a.ctx, id, a.market(), groups, timelineReleasesPerGroup)
+	if err != nil {
+		log.Printf("Error getting latest releases: %v", err)
+		return nil, err
 	}
-	return result
+	return result, nil
 }
 
 // GetAlbum retrieves album data from Spotify by ID
-func (a *App) GetAlbum(id string) map[string]any {
-	result, err := api.GetAlbumDetails(id, a.spotifyAccessToken)
+func (a *App) GetAlbum(id string) (*api.AlbumDetails, error) {
+	result, err := a.spotify.GetAlbumDetails(a.ctx, id, a.market())
 	if err != nil {
 		log.Printf("Error getting album: %v", err)
-		return map[string]any{}
+		return nil, err
 	}
-	return result
+	return result, nil
 }
 
 // GetTrack retrieves track data from Spotify by ID
-func (a *App) GetTrack(id string) map[string]any {
-	result, err := api.GetTrackDetails(id, a.spotifyAccessToken)
+func (a *App) GetTrack(id string) (*api.TrackDetails, error) {
+	result, err := a.spotify.GetTrackDetails(a.ctx, id, a.market())
 	if err != nil {
 		log.Printf("Error getting track: %v", err)
-		return map[string]any{}
+		return nil, err
 	}
-	return result
+	return result, nil
+}
+
+// GetPlaylist retrieves playlist data from Spotify by ID
+func (a *App) GetPlaylist(id string) (*api.PlaylistDetails, error) {
+	result, err := a.spotify.GetPlaylistDetails(a.ctx, id, a.market())
+	if err != nil {
+		log.Printf("Error getting playlist: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// ResolveLink normalizes any Spotify link, URI or short link pasted by the
+// user into the kind of object it points to and its ID
+func (a *App) ResolveLink(link string) (*api.Link, error) {
+	result, err := a.spotify.ResolveLink(a.ctx, link)
+	if err != nil {
+		log.Printf("Error resolving link: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// canonicalLink resolves link to its canonical open.spotify.com URL
+func (a *App) canonicalLink(ctx context.Context, link string) (string, error) {
+	result, err := a.spotify.ResolveLink(ctx, link)
+	if err != nil {
+		return "", err
+	}
+	return result.URL, nil
 }
 
 // AddArtist adds an artist to the database by Spotify ID
@@ -129,9 +226,46 @@ func (a *App) AddArtist(spotifyID string) bool {
 		log.Printf("Error adding artist: %v", err)
 		return false
 	}
+
+	// Cache the artist's name and picture for the subscriptions view, and
+	// seed its releases so that only later ones show up as new
+	go func() {
+		if _, err := a.refreshArtistMetadata(a.ctx, []string{spotifyID}); err != nil {
+			log.Printf("Error caching metadata for artist %s: %v", spotifyID, err)
+		}
+		a.seedReleases(a.ctx)
+	}()
+
 	return success
 }
 
+// refreshArtistMetadata fetches artists in bulk and caches their metadata in
+// the database. It returns the artists found on Spotify keyed by ID.
+func (a *App) refreshArtistMetadata(ctx context.Context, ids []string) (map[string]api.Artist, error) {
+	artists, err := a.spotify.GetSeveralArtists(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]api.Artist, len(artists))
+	for _, artist := range artists {
+		found[artist.ID] = artist
+
+		meta := database.ArtistMetadata{
+			Name:      artist.Name,
+			Genres:    artist.Genres,
+			Followers: artist.Followers.Total,
+		}
+		if len(artist.Images) > 0 {
+			meta.ImageURL = artist.Images[0].URL
+		}
+		if err := a.db.UpdateArtistMetadata(artist.ID, meta); err != nil {
+			log.Printf("Error caching metadata for artist %s: %v", artist.ID, err)
+		}
+	}
+	return found, nil
+}
+
 // RemoveArtist removes an artist from the database by Spotify ID
 func (a *App) RemoveArtist(spotifyID string) bool {
 	success, err := a.db.RemoveArtist(spotifyID)
@@ -142,7 +276,8 @@ func (a *App) RemoveArtist(spotifyID string) bool {
 	return success
 }
 
-// GetArtistsFromDB retrieves all artists from the database
+// GetArtistsFromDB retrieves all artists from the database, along with
+// their cached name, picture, genres and follower count
 func (a *App) GetArtistsFromDB() []database.Artist {
 	artists, err := a.db.GetArtistsFromDB()
 	if err != nil {
@@ -152,8 +287,87 @@ func (a *App) GetArtistsFromDB() []database.Artist {
 	return artists
 }
 
+// ================ Release Groups =================
+
+// defaultReleaseGroupsKey stores the release groups checked for artists without their own filter
+const defaultReleaseGroupsKey = "default_release_groups"
+
+// normalizeReleaseGroups validates groups and removes duplicates
+func normalizeReleaseGroups(groups []string) ([]string, error) {
+	seen := make(map[string]bool, len(groups))
+	normalized := make([]string, 0, len(groups))
+	for _, group := range groups {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if !api.IsValidReleaseGroup(group) {
+			return nil, fmt.Errorf("invalid release group %q", group)
+		}
+		if !seen[group] {
+			seen[group] = true
+			normalized = append(normalized, group)
+		}
+	}
+	return normalized, nil
+}
+
+// defaultReleaseGroups returns the global release group filter
+func (a *App) defaultReleaseGroups() []string {
+	value, err := a.db.GetSetting(defaultReleaseGroupsKey)
+	if err != nil {
+		log.Printf("Error getting default release groups: %v", err)
+	}
+	if groups, err := normalizeReleaseGroups(strings.Split(value, ",")); value != "" && err == nil {
+		return groups
+	}
+	return api.DefaultReleaseGroups
+}
+
+// releaseGroupsFor returns the release groups checked for artist
+func (a *App) releaseGroupsFor(artist database.Artist) []string {
+	if len(artist.ReleaseGroups) > 0 {
+		return artist.ReleaseGroups
+	}
+	return a.defaultReleaseGroups()
+}
+
+// GetDefaultReleaseGroups returns the release groups checked for artists without their own filter
+func (a *App) GetDefaultReleaseGroups() []string {
+	return a.defaultReleaseGroups()
+}
+
+// SetDefaultReleaseGroups sets the release groups checked for artists without their own filter
+func (a *App) SetDefaultReleaseGroups(groups []string) error {
+	normalized, err := normalizeReleaseGroups(groups)
+	if err != nil {
+		return err
+	}
+	if len(normalized) == 0 {
+		return fmt.Errorf("at least one release group is required")
+	}
+	if err := a.db.SetSetting(defaultReleaseGroupsKey, strings.Join(normalized, ",")); err != nil {
+		log.Printf("Error setting default release groups: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SetArtistReleaseGroups sets the release groups checked for a subscribed
+// artist, for instance to add appears_on or mute singles. An empty list
+// reverts to the global default.
+func (a *App) SetArtistReleaseGroups(spotifyID string, groups []string) error {
+	normalized, err := normalizeReleaseGroups(groups)
+	if err != nil {
+		return err
+	}
+	if err := a.db.SetArtistReleaseGroups(spotifyID, normalized); err != nil {
+		log.Printf("Error setting release groups for artist %s: %v", spotifyID, err)
+		return err
+	}
+	return nil
+}
+
 // ================ Generic Settings =================
-// AppGetSetting retrieves a setting value by its key.
+// AppGetSetting retrieves a setting value by its key. Secrets such as the
+// Spotify client secret are never returned.
 func (a *App) GetSetting(key string) (string, error) {
 	value, err := a.db.GetSetting(key)
 	if err != nil {
@@ -172,11 +386,328 @@ func (a *App) SetSetting(key string, value string) error {
 	return err
 }
 
+// ================ Response Cache =================
+
+// CacheStats is shown in the settings page
+type CacheStats struct {
+	Stored   database.CacheStats `json:"stored"`
+	Requests api.CacheCounters   `json:"requests"`
+}
+
+// GetCacheStats returns the size of the Spotify response cache and how
+// requests were served since the app started
+func (a *App) GetCacheStats() (CacheStats, error) {
+	stats, err := a.db.GetCacheStats()
+	if err != nil {
+		log.Printf("Error getting cache stats: %v", err)
+		return CacheStats{}, err
+	}
+	return CacheStats{Stored: stats, Requests: a.spotify.CacheCounters()}, nil
+}
+
+// ClearCache deletes every cached Spotify response
+func (a *App) ClearCache() error {
+	if err := a.db.ClearCache(); err != nil {
+		log.Printf("Error clearing cache: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ================ Download History =================
+
+// newDownloader creates the downloader. It resolves links through the
+// Spotify client, records its runs in the download history and persists its
+// queue, with the stored number of workers and executables.
+func (a *App) newDownloader() *spotdl.Downloader {
+	d, downloads := spotdl.NewDownloader(spotdl.Options{
+		JobStore:     jobStore{db: a.db},
+		History:      downloadHistory{db: a.db},
+		LinkResolver: a.canonicalLink,
+	})
+	if err := downloads.SetWorkers(a.downloadWorkers()); err != nil {
+		log.Printf("Error setting parallel downloads: %v", err)
+	}
+	spotdlPath, ffmpegPath := a.executablePaths()
+	downloads.SetExecutablePaths(spotdlPath, ffmpegPath)
+	a.downloader, a.downloads = d, downloads
+	return d
+}
+
+// downloadHistory stores the downloader's runs in the database
+type downloadHistory struct {
+	db *database.Database
+}
+
+func (h downloadHistory) Start(r spotdl.Record) (int64, error) {
+	dl := database.Download{
+		Link:       r.Link,
+		OutputPath: r.OutputPath,
+		Format:     r.Format,
+		Bitrate:    r.Bitrate,
+		StartedAt:  r.StartedAt,
+		Status:     r.Status,
+	}
+	// Short links are only known once the downloader resolved them
+	if link, err := api.ParseLink(r.Link); err == nil {
+		dl.Kind, dl.SpotifyID = string(link.Kind), link.ID
+	}
+	return h.db.AddDownload(dl)
+}
+
+func (h downloadHistory) Finish(id int64, r spotdl.Record) error {
+	dl, err := h.db.GetDownload(id)
+	if err != nil {
+		return err
+	}
+	if dl == nil {
+		return fmt.Errorf("download %d not found", id)
+	}
+	if link, err := api.ParseLink(r.ResolvedLink); err == nil {
+		dl.Kind, dl.SpotifyID = string(link.Kind), link.ID
+	}
+	dl.FinishedAt = r.FinishedAt
+	dl.Status = r.Status
+	dl.Error = r.Error
+	dl.Files = r.Files
+	return h.db.UpdateDownload(*dl)
+}
+
+// GetDownloads returns the download history matching filter, most recent first
+func (a *App) GetDownloads(filter database.DownloadFilter) ([]database.Download, error) {
+	downloads, err := a.db.GetDownloads(filter)
+	if err != nil {
+		log.Printf("Error getting downloads: %v", err)
+		return nil, err
+	}
+	return downloads, nil
+}
+
+// DeleteDownload removes an entry from the download history, keeping its files
+func (a *App) DeleteDownload(id int64) error {
+	if err := a.db.DeleteDownload(id); err != nil {
+		log.Printf("Error deleting download %d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// ClearDownloads removes every finished entry from the download history
+func (a *App) ClearDownloads() error {
+	if err := a.db.ClearDownloads(); err != nil {
+		log.Printf("Error clearing downloads: %v", err)
+		return err
+	}
+	return nil
+}
+
+// RerunDownload downloads the link of a history entry again with the same
+// output path, format and bitrate. The new run gets its own history entry.
+func (a *App) RerunDownload(id int64) (bool, error) {
+	if a.downloader == nil {
+		return false, fmt.Errorf("downloader is not available")
+	}
+	dl, err := a.db.GetDownload(id)
+	if err != nil {
+		log.Printf("Error getting download %d: %v", id, err)
+		return false, err
+	}
+	if dl == nil {
+		return false, fmt.Errorf("download %d not found", id)
+	}
+	return a.downloader.Download(dl.Link, dl.OutputPath, dl.Format, dl.Bitrate, nil), nil
+}
+
+// ================ Download Queue =================
+
+// downloadWorkersKey stores how many downloads run in parallel
+const downloadWorkersKey = "download_workers"
+
+// downloadWorkers returns the configured number of parallel downloads
+func (a *App) downloadWorkers() int {
+	value, err := a.db.GetSetting(downloadWorkersKey)
+	if err != nil {
+		log.Printf("Error getting %s setting: %v", downloadWorkersKey, err)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return spotdl.DefaultWorkers
+	}
+	return n
+}
+
+// GetDownloadWorkers returns how many downloads run in parallel
+func (a *App) GetDownloadWorkers() int {
+	return a.downloadWorkers()
+}
+
+// SetDownloadWorkers sets and stores how many downloads run in parallel
+func (a *App) SetDownloadWorkers(n int) error {
+	if a.downloader == nil {
+		return fmt.Errorf("downloader is not available")
+	}
+	if err := a.downloads.SetWorkers(n); err != nil {
+		return err
+	}
+	if err := a.db.SetSetting(downloadWorkersKey, strconv.Itoa(n)); err != nil {
+		log.Printf("Error setting %s: %v", downloadWorkersKey, err)
+		return err
+	}
+	return nil
+}
+
+// Settings holding the spotdl and ffmpeg executables chosen by the user
+const (
+	spotdlPathKey = "spotdl_path"
+	ffmpegPathKey = "ffmpeg_path"
+)
+
+// executablePaths returns the configured spotdl and ffmpeg executables, empty when unset
+func (a *App) executablePaths() (spotdlPath, ffmpegPath string) {
+	spotdlPath, err := a.db.GetSetting(spotdlPathKey)
+	if err != nil {
+		log.Printf("Error getting %s setting: %v", spotdlPathKey, err)
+	}
+	ffmpegPath, err = a.db.GetSetting(ffmpegPathKey)
+	if err != nil {
+		log.Printf("Error getting %s setting: %v", ffmpegPathKey, err)
+	}
+	return spotdlPath, ffmpegPath
+}
+
+// SetExecutablePaths stores the spotdl and ffmpeg executables to use instead
+// of the ones on PATH and the bundled ones. An empty path removes the
+// preference. Use the downloader's Diagnostics to check that they run.
+func (a *App) SetExecutablePaths(spotdlPath, ffmpegPath string) error {
+	for key, path := range map[string]string{spotdlPathKey: spotdlPath, ffmpegPathKey: ffmpegPath} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("invalid %s: %s is a directory", key, path)
+		}
+	}
+
+	if err := a.db.SetSetting(spotdlPathKey, spotdlPath); err != nil {
+		log.Printf("Error setting %s: %v", spotdlPathKey, err)
+		return err
+	}
+	if err := a.db.SetSetting(ffmpegPathKey, ffmpegPath); err != nil {
+		log.Printf("Error setting %s: %v", ffmpegPathKey, err)
+		return err
+	}
+	if a.downloader != nil {
+		a.downloads.SetExecutablePaths(spotdlPath, ffmpegPath)
+	}
+	return nil
+}
+
+// ChooseExecutable opens a file selection dialog for a spotdl or ffmpeg executable
+func (a *App) ChooseExecutable() string {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Executable",
+	})
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// jobStore persists the download queue in the database
+type jobStore struct {
+	db *database.Database
+}
+
+func (s jobStore) CreateJob(job spotdl.Job) (int64, error) {
+	return s.db.AddDownloadJob(database.DownloadJob(job))
+}
+
+func (s jobStore) UpdateJob(job spotdl.Job) error {
+	return s.db.UpdateDownloadJob(database.DownloadJob(job))
+}
+
+func (s jobStore) DeleteJob(id int64) error {
+	return s.db.DeleteDownloadJob(id)
+}
+
+func (s jobStore) ListJobs() ([]spotdl.Job, error) {
+	stored, err := s.db.GetDownloadJobs()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]spotdl.Job, len(stored))
+	for i, job := range stored {
+		jobs[i] = spotdl.Job(job)
+	}
+	return jobs, nil
+}
+
+// ================ Market =================
+
+// marketSettingKey stores the user's market, either a country code or "auto"
+const marketSettingKey = "market"
+
+// market returns the market passed to Spotify calls: the configured
+// country code, or the one detected from the system locale
+func (a *App) market() string {
+	market, err := a.db.GetSetting(marketSettingKey)
+	if err != nil {
+		log.Printf("Error getting market setting: %v", err)
+	}
+	if api.IsValidMarket(market) {
+		return market
+	}
+	return api.DetectMarket()
+}
+
+// GetMarket returns the market used for Spotify calls
+func (a *App) GetMarket() string {
+	return a.market()
+}
+
+// SetMarket stores the market as an ISO 3166-1 alpha-2 country code, or
+// "auto" to detect it from the system locale
+func (a *App) SetMarket(market string) error {
+	market = strings.ToUpper(strings.TrimSpace(market))
+	if market == "" || market == "AUTO" {
+		market = "auto"
+	} else if !api.IsValidMarket(market) {
+		return fmt.Errorf("invalid market %q: expected a two-letter country code", market)
+	}
+
+	if err := a.db.SetSetting(marketSettingKey, market); err != nil {
+		log.Printf("Error setting market: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DetectMarket returns the market guessed from the system locale
+func (a *App) DetectMarket() string {
+	return api.DetectMarket()
+}
+
 // ================ Spotify Credentials Specific =================
 
+// ValidateAndStoreSpotifyCredentials checks the credentials against Spotify
+// and stores them. An empty secret keeps the stored one, since the frontend
+// never gets to read it back.
 func (a *App) ValidateAndStoreSpotifyCredentials(clientID, clientSecret string) bool {
+	if clientSecret == "" {
+		stored, err := a.db.GetSecret("spotify_client_secret")
+		if err != nil {
+			log.Printf("Error reading stored spotify_client_secret: %v", err)
+			return false
+		}
+		clientSecret = stored
+	}
+
 	// First check if the credentials are valid by trying to get a token
-	token, _, err := api.GetToken(clientID, clientSecret)
+	token, _, err := a.spotify.GetToken(a.ctx, clientID, clientSecret)
 	if err != nil || token == "" {
 		log.Printf("Validation of Spotify credentials failed: %v", err)
 		return false
@@ -194,22 +725,18 @@ func (a *App) ValidateAndStoreSpotifyCredentials(clientID, clientSecret string)
 	}
 
 	log.Println("Spotify credentials validated and stored successfully.")
-	a.fetchSpotifyAccessToken()
+	a.spotify.ResetToken()
 	return true
 }
 
 // HasValidSpotifyCredentials checks if the stored credentials are valid
 func (a *App) HasValidSpotifyCredentials() bool {
-	clientID, err := a.db.GetSetting("spotify_client_id")
-	if err != nil || clientID == "" {
-		return false
-	}
-	clientSecret, err := a.db.GetSetting("spotify_client_secret")
-	if err != nil || clientSecret == "" {
+	creds, err := a.db.GetSpotifyCredentials()
+	if err != nil || creds.ClientID == "" || creds.ClientSecret == "" {
 		return false
 	}
 
-	token, _, errApi := api.GetToken(clientID, clientSecret)
+	token, _, errApi := a.spotify.GetToken(a.ctx, creds.ClientID, creds.ClientSecret)
 	isValid := errApi == nil && token != ""
 	return isValid
 }
@@ -225,61 +752,247 @@ func (a *App) ChooseDirectory() string {
 	return dir
 }
 
+// ================ Export / Import =================
+
+// exportFileFilter restricts the export dialogs to JSON files
+var exportFileFilter = []runtime.FileFilter{{DisplayName: "Spotwrap export (*.json)", Pattern: "*.json"}}
+
+// ExportData writes the subscriptions and settings to path as JSON. The
+// decrypted secrets are included only when includeSecrets is set.
+func (a *App) ExportData(path string, includeSecrets bool) error {
+	doc, err := a.db.Export(includeSecrets)
+	if err != nil {
+		log.Printf("Error exporting data: %v", err)
+		return err
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The export may contain secrets, keep it private like the config directory
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		log.Printf("Error writing export to %s: %v", path, err)
+		return err
+	}
+	log.Printf("Exported %d artists and %d settings to %s", len(doc.Artists), len(doc.Settings), path)
+	return nil
+}
+
+// ImportData reads an export written by ExportData and applies it. Mode is
+// "merge", keeping existing values on conflict, or "replace", making the
+// subscriptions and settings match the file.
+func (a *App) ImportData(path, mode string) (database.ImportReport, error) {
+	report, _, err := a.importData(path, mode)
+	return report, err
+}
+
+// validateImport checks the release groups and the market of doc before
+// anything is written
+func validateImport(doc *database.ExportDocument) error {
+	for _, artist := range doc.Artists {
+		for _, group := range artist.ReleaseGroups {
+			if !api.IsValidReleaseGroup(group) {
+				return fmt.Errorf("invalid release group %q for artist %s", group, artist.SpotifyID)
+			}
+		}
+	}
+	if value := doc.Settings[defaultReleaseGroupsKey]; value != "" {
+		for _, group := range strings.Split(value, ",") {
+			if !api.IsValidReleaseGroup(group) {
+				return fmt.Errorf("invalid release group %q in %s", group, defaultReleaseGroupsKey)
+			}
+		}
+	}
+	if market, ok := doc.Settings[marketSettingKey]; ok && market != "auto" && !api.IsValidMarket(market) {
+		return fmt.Errorf("invalid market %q: expected a two-letter country code or auto", market)
+	}
+	return nil
+}
+
+// importData is ImportData for callers that need the imported artists'
+// metadata. The returned channel is closed once it is cached.
+func (a *App) importData(path, mode string) (database.ImportReport, <-chan struct{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Error reading import from %s: %v", path, err)
+		return database.ImportReport{}, nil, err
+	}
+	var doc database.ExportDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return database.ImportReport{}, nil, fmt.Errorf("invalid export file: %w", err)
+	}
+	if err := validateImport(&doc); err != nil {
+		return database.ImportReport{}, nil, err
+	}
+
+	report, err := a.db.Import(&doc, mode)
+	if err != nil {
+		log.Printf("Error importing data: %v", err)
+		return report, nil, err
+	}
+	log.Printf("Imported %s: %d artists added, %d updated, %d removed, %d settings changed, %d conflicts",
+		path, report.ArtistsAdded, report.ArtistsUpdated, report.ArtistsRemoved, report.SettingsChanged, len(report.Conflicts))
+
+	// The credentials may have been replaced or removed
+	if report.SettingsChanged > 0 {
+		a.spotify.ResetToken()
+	}
+	done := make(chan struct{})
+	if report.ArtistsAdded > 0 {
+		ids := make([]string, 0, len(doc.Artists))
+		for _, artist := range doc.Artists {
+			ids = append(ids, artist.SpotifyID)
+		}
+		go func() {
+			defer close(done)
+			if _, err := a.refreshArtistMetadata(a.ctx, ids); err != nil {
+				log.Printf("Error caching metadata for imported artists: %v", err)
+			}
+		}()
+	} else {
+		close(done)
+	}
+	return report, done, nil
+}
+
+// ChooseExportFile opens a save dialog for an export file
+func (a *App) ChooseExportFile() string {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Data",
+		DefaultFilename: "spotwrap-export.json",
+		Filters:         exportFileFilter,
+	})
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// ChooseImportFile opens a file selection dialog for an export file
+func (a *App) ChooseImportFile() string {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Import Data",
+		Filters: exportFileFilter,
+	})
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
 // ================ Utils =================
-func (a *App) IsANewRelease(id string, release map[string]any) bool {
+
+// IsANewRelease reports whether release has not been seen yet for the
+// artist. Releases of artists whose releases were not seeded yet are never
+// new, otherwise their whole discography would be.
+func (a *App) IsANewRelease(id string, release api.SimplifiedAlbum) bool {
 	artist, err := a.db.GetArtistByID(id)
 	if err != nil {
-		fmt.Println("Error getting artist:", err)
+		if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Println("Error checking release:", err)
+		}
+		return false
+	}
+	if artist.SyncedAt.IsZero() {
 		return false
 	}
 
-	// Extract release date from the album
-	releaseDateStr, ok := release["release_date"].(string)
-	if !ok {
+	seen, err := a.db.IsReleaseSeen(release.ID, id)
+	if err != nil {
+		fmt.Println("Error checking release:", err)
 		return false
 	}
+	return !seen
+}
+
+// MarkReleaseSeen records a release as seen so that it is no longer new.
+// The user saw it, so no notification is sent for it.
+func (a *App) MarkReleaseSeen(id string, release api.SimplifiedAlbum) error {
+	isNew, err := a.recordRelease(id, release, true)
+	if err == nil && !isNew {
+		err = a.db.MarkReleaseNotified(release.ID, id)
+	}
+	if err != nil {
+		log.Printf("Error marking release %s as seen: %v", release.ID, err)
+		return err
+	}
+	return nil
+}
 
-	// Parse the release date (handles different formats: YYYY, YYYY-MM, YYYY-MM-DD)
-	var releaseDate time.Time
-	switch len(releaseDateStr) {
-	case 4: // YYYY
-		releaseDate, _ = time.Parse("2006", releaseDateStr)
-	case 7: // YYYY-MM
-		releaseDate, _ = time.Parse("2006-01", releaseDateStr)
-	default: // YYYY-MM-DD
-		releaseDate, _ = time.Parse("2006-01-02", releaseDateStr)
+// recordRelease stores release in the releases table, returning true when
+// it had not been seen before for the artist. Unless notified is set, the
+// next check notifies about it.
+func (a *App) recordRelease(artistID string, release api.SimplifiedAlbum, notified bool) (bool, error) {
+	releaseType := release.AlbumGroup
+	if releaseType == "" {
+		releaseType = release.AlbumType
 	}
+	return a.db.AddRelease(database.Release{
+		AlbumID:     release.ID,
+		ArtistID:    artistID,
+		Name:        release.Name,
+		Type:        releaseType,
+		ReleaseDate: release.ReleaseDate,
+		FirstSeen:   time.Now(),
+		Notified:    notified,
+	})
+}
 
-	return releaseDate.After(artist.LastChecked)
+// seedReleases records the current releases of the subscribed artists that
+// were never synced, without notifying. The GUI runs it at startup and on
+// subscribe so that it doesn't depend on the --no-gui checker to know which
+// releases are new.
+func (a *App) seedReleases(ctx context.Context) {
+	artists, err := a.db.GetArtistsFromDB()
+	if err != nil {
+		log.Printf("Error getting artists to seed: %v", err)
+		return
+	}
+
+	market := a.market()
+	for _, artist := range artists {
+		if !artist.SyncedAt.IsZero() {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err := a.syncReleases(ctx, artist, artist.Name, market); err != nil {
+			log.Printf("Error seeding releases of %s: %v", artist.SpotifyID, err)
+		}
+	}
 }
 
 // Background
 func (a *App) startBackgroundChecker() {
 	a.backgroundTicker = time.NewTicker(5 * time.Hour)
-	a.backgroundDone = make(chan bool)
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.backgroundCancel = cancel
 
 	go func() {
 		for {
 			select {
 			case <-a.backgroundTicker.C:
-				a.checkForNewReleases()
-			case <-a.backgroundDone:
+				a.checkForNewReleases(ctx)
+			case <-ctx.Done():
 				return
 			}
 		}
 	}()
 }
 
+// stopBackgroundChecker stops the ticker and aborts a check in progress
+// without waiting for it
 func (a *App) stopBackgroundChecker() {
 	if a.backgroundTicker != nil {
 		a.backgroundTicker.Stop()
 	}
-	if a.backgroundDone != nil {
-		a.backgroundDone <- true
+	if a.backgroundCancel != nil {
+		a.backgroundCancel()
 	}
 }
 
-func (a *App) checkForNewReleases() {
+func (a *App) checkForNewReleases(ctx context.Context) {
 	fmt.Println("Starting background check for new releases...")
 
 	// Get artists that need checking
@@ -294,53 +1007,104 @@ func (a *App) checkForNewReleases() {
 		return
 	}
 
-	a.fetchSpotifyAccessToken()
+	// Refresh the cached metadata of every artist in bulk, 50 per request
+	ids := make([]string, len(artists))
+	for i, artist := range artists {
+		ids[i] = artist.SpotifyID
+	}
+	found, err := a.refreshArtistMetadata(ctx, ids)
+	if err != nil {
+		// Not fatal, the cached names are good enough for the check
+		fmt.Printf("Error refreshing artists metadata: %v\n", err)
+	}
 
+	market := a.market()
 	for _, artist := range artists {
-		fmt.Printf("Checking for new releases from artist %s...\n", artist.SpotifyID)
+		if ctx.Err() != nil {
+			fmt.Println("Background check cancelled")
+			return
+		}
 
-		// Get artist's latest albums with retry mechanism
-		artistData, err := api.GetArtistDetails(artist.SpotifyID, a.spotifyAccessToken, true)
-		if err != nil {
-			fmt.Printf("Error getting artist details for %s: %v\n", artist.SpotifyID, err)
+		artistName := artist.Name
+		if info, ok := found[artist.SpotifyID]; ok {
+			artistName = info.Name
+		} else if err == nil {
+			fmt.Printf("Artist %s not found on Spotify, skipping\n", artist.SpotifyID)
 			continue
 		}
+		if artistName == "" {
+			artistName = artist.SpotifyID
+		}
 
-		// Check albums for new releases
-		albums, ok := artistData["albums"].([]any)
-		if !ok {
-			fmt.Printf("Unexpected albums format for artist %s\n", artist.SpotifyID)
+		fmt.Printf("Checking for new releases from %s (%s)...\n", artistName, artist.SpotifyID)
+		if err := a.syncReleases(ctx, artist, artistName, market); err != nil {
+			fmt.Printf("Error getting albums for %s: %v\n", artist.SpotifyID, err)
 			continue
 		}
 
-		for _, album := range albums {
-			albumMap, ok := album.(map[string]any)
-			if !ok {
-				continue
-			}
+		// Update last checked time
+		if _, err := a.db.AddArtist(artist.SpotifyID); err != nil {
+			fmt.Printf("Error updating last_checked for artist %s: %v\n", artist.SpotifyID, err)
+		}
+	}
 
-			if a.IsANewRelease(artist.SpotifyID, albumMap) {
-				fmt.Printf("New release found for artist %s: %v\n", artist.SpotifyID, albumMap["name"])
+	fmt.Println("Background check completed")
+}
 
-				albumName := albumMap["name"].(string)
-				artistName := artistData["artist"].(map[string]any)["name"].(string)
-				message := fmt.Sprintf("%s has released %s", artistName, albumName)
+// syncReleases records the releases of artist and notifies about the ones
+// not seen before. The first sync after subscribing only seeds the releases
+// table. A release stays pending until its notification was sent, so a
+// failed one is retried at the next check.
+func (a *App) syncReleases(ctx context.Context, artist database.Artist, artistName, market string) error {
+	if artistName == "" {
+		artistName = artist.SpotifyID
+	}
 
-				// Send desktop notification
-				err := notifications.Notify("New Release!", message)
-				if err != nil {
-					fmt.Printf("Failed to send notification: %v\n", err)
-				}
-			}
+	// Only the discography has to be fetched per artist
+	albums, err := a.spotify.GetArtistAlbums(ctx, artist.SpotifyID, market, a.releaseGroupsFor(artist), 0)
+	if err != nil {
+		return err
+	}
+
+	seeding := artist.SyncedAt.IsZero()
+
+	// A release is new when its ID hasn't been seen for this artist
+	for _, album := range albums {
+		isNew, err := a.recordRelease(artist.SpotifyID, album, seeding)
+		if err != nil {
+			fmt.Printf("Error recording release %s: %v\n", album.ID, err)
+			continue
+		}
+		if isNew && !seeding {
+			fmt.Printf("New release found for artist %s: %s\n", artist.SpotifyID, album.Name)
 		}
+	}
 
-		// Update last checked time
-		if _, err := a.db.AddArtist(artist.SpotifyID); err != nil {
-			fmt.Printf("Error updating last_checked for artist %s: %v\n", artist.SpotifyID, err)
+	if seeding {
+		fmt.Printf("Seeded %d releases for %s\n", len(albums), artistName)
+		if err := a.db.MarkArtistSynced(artist.SpotifyID); err != nil {
+			fmt.Printf("Error marking artist %s as synced: %v\n", artist.SpotifyID, err)
 		}
+		return nil
 	}
 
-	fmt.Println("Background check completed")
+	pending, err := a.db.PendingReleases(artist.SpotifyID)
+	if err != nil {
+		return err
+	}
+	for _, release := range pending {
+		message := fmt.Sprintf("%s has released %s", artistName, release.Name)
+
+		// Send desktop notification
+		if err := notifications.Notify("New Release!", message); err != nil {
+			fmt.Printf("Failed to send notification: %v\n", err)
+			continue
+		}
+		if err := a.db.MarkReleaseNotified(release.AlbumID, artist.SpotifyID); err != nil {
+			fmt.Printf("Error marking release %s as notified: %v\n", release.AlbumID, err)
+		}
+	}
+	return nil
 }
 
 // ================ Update Checker =================
@@ -381,5 +1145,9 @@ func (a *App) CheckForUpdates() map[string]any {
 }
 
 func (a *App) Close() {
+	// Cancel pending Spotify requests and retry sleeps
+	if a.cancel != nil {
+		a.cancel()
+	}
 	a.db.Close()
 }
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// busyTimeout is how long SQLite itself waits on a lock before giving up
+	busyTimeout = 5 * time.Second
+	// busyRetries is how many times a write is retried after SQLite gave up
+	busyRetries = 5
+	// busyBackoff is the first delay between retries, doubled after each one
+	busyBackoff = 100 * time.Millisecond
+)
+
+// isBusy reports whether err means another connection or process holds the database lock
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// retryOnBusy runs fn until it succeeds, fails with an error other than a
+// busy database, or runs out of retries. The GUI and the --no-gui
+// background process share the database file, so writes can collide.
+func retryOnBusy(fn func() error) error {
+	delay := busyBackoff
+	var err error
+	for attempt := 0; attempt <= busyRetries; attempt++ {
+		if err = fn(); !isBusy(err) {
+			return err
+		}
+		if attempt < busyRetries {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// exec runs a write statement, retrying while the database is busy
+func (d *Database) exec(query string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := retryOnBusy(func() error {
+		var err error
+		res, err = d.db.Exec(query, args...)
+		return err
+	})
+	return res, err
+}
+
+// withTx runs fn in a transaction, committing if it succeeds and rolling back
+// otherwise. The whole transaction is retried while the database is busy.
+func (d *Database) withTx(fn func(tx *sql.Tx) error) error {
+	return retryOnBusy(func() error {
+		tx, err := d.db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
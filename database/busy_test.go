@@ -0,0 +1,137 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Environment of the helper process started by TestConcurrentProcesses
+const (
+	helperDirEnv   = "SPOTWRAP_DB_HELPER_DIR"
+	helperStartEnv = "SPOTWRAP_DB_HELPER_START"
+)
+
+// writesPerProcess is how many AddArtist calls and transactions each process runs
+const writesPerProcess = 200
+
+// hammer writes to the database like the GUI and the --no-gui checker do,
+// with both single statements and transactions, and returns every error
+func hammer(d *Database, name string) []error {
+	var errs []error
+	for i := 0; i < writesPerProcess; i++ {
+		id := fmt.Sprintf("%s-%d", name, i)
+		if _, err := d.AddArtist(id); err != nil {
+			errs = append(errs, fmt.Errorf("AddArtist %s: %w", id, err))
+		}
+		err := d.withTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec("UPDATE artists SET name = ? WHERE spotify_id = ?", id, id); err != nil {
+				return err
+			}
+			_, err := tx.Exec(
+				"INSERT INTO releases (album_id, artist_id, name, type, release_date, first_seen, notified) VALUES (?, ?, '', 'album', '', ?, 0)",
+				id, id, time.Now(),
+			)
+			return err
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("withTx %s: %w", id, err))
+		}
+	}
+	return errs
+}
+
+// waitUntil sleeps until the Unix time in nanoseconds held by value
+func waitUntil(value string) {
+	if ns, err := strconv.ParseInt(value, 10, 64); err == nil {
+		time.Sleep(time.Until(time.Unix(0, ns)))
+	}
+}
+
+// TestHelperProcess is the second process of TestConcurrentProcesses. It
+// does nothing unless started by it.
+func TestHelperProcess(t *testing.T) {
+	dir := os.Getenv(helperDirEnv)
+	if dir == "" {
+		t.Skip("only run by TestConcurrentProcesses")
+	}
+
+	d, err := openDir(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open: %v\n", err)
+		os.Exit(1)
+	}
+	defer d.Close()
+
+	waitUntil(os.Getenv(helperStartEnv))
+	if errs := hammer(d, "helper"); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
+
+// TestConcurrentProcesses writes to the same database from this process and
+// a helper process at the same time, as the GUI and the --no-gui background
+// checker do, and checks that no write fails with a locked database
+func TestConcurrentProcesses(t *testing.T) {
+	dir := t.TempDir()
+	d, err := openDir(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer d.Close()
+
+	// Both processes start writing at the same time, once the helper is up
+	start := time.Now().Add(2 * time.Second).UnixNano()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(),
+		helperDirEnv+"="+dir,
+		helperStartEnv+"="+strconv.FormatInt(start, 10),
+	)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start helper: %v", err)
+	}
+
+	// Two goroutines as well, the GUI writes from several at once
+	var wg sync.WaitGroup
+	errs := make([][]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			waitUntil(strconv.FormatInt(start, 10))
+			errs[i] = hammer(d, fmt.Sprintf("parent%d", i))
+		}()
+	}
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("helper failed: %v\n%s", err, stderr.String())
+	}
+	for _, list := range errs {
+		for _, err := range list {
+			t.Error(err)
+		}
+	}
+
+	var artists, releases int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM artists").Scan(&artists); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM releases").Scan(&releases); err != nil {
+		t.Fatal(err)
+	}
+	if want := 3 * writesPerProcess; artists != want || releases != want {
+		t.Errorf("got %d artists and %d releases, want %d of each", artists, releases, want)
+	}
+}
@@ -1,25 +1,115 @@
 package database
 
 import (
+	"crypto/cipher"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Database struct {
-	db *sql.DB
+	db          *sql.DB
+	secrets     cipher.AEAD  // encrypts the settings listed in secretKeys
+	cacheWrites atomic.Int64 // responses cached since the last prune
 }
 
 type Artist struct {
-	SpotifyID   string
-	LastChecked time.Time
-	CreatedAt   time.Time
+	SpotifyID     string
+	LastChecked   time.Time
+	CreatedAt     time.Time
+	ReleaseGroups []string  // release groups to check, empty means the global default
+	SyncedAt      time.Time // first sync of the releases table, zero until the artist was seeded
+
+	// Metadata cached from Spotify so the subscriptions view works offline
+	Name              string
+	ImageURL          string
+	Genres            []string
+	Followers         int
+	MetadataUpdatedAt time.Time
+}
+
+// ArtistMetadata is the Spotify metadata cached in the artists table
+type ArtistMetadata struct {
+	Name      string
+	ImageURL  string
+	Genres    []string
+	Followers int
+}
+
+// Release is a release of a subscribed artist seen by the background checker
+type Release struct {
+	AlbumID     string
+	ArtistID    string
+	Name        string
+	Type        string
+	ReleaseDate string
+	FirstSeen   time.Time
+	Notified    bool
+}
+
+// artistColumns lists the artists columns in the order scanArtist expects them
+const artistColumns = "spotify_id, last_checked, created_at, release_groups, releases_synced_at, " +
+	"name, image_url, genres, follower_count, metadata_updated_at"
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanArtist reads an artist selected with artistColumns
+func scanArtist(row rowScanner) (Artist, error) {
+	var a Artist
+	var releaseGroups, genres string
+	var syncedAt, metadataUpdatedAt sql.NullTime
+	err := row.Scan(
+		&a.SpotifyID, &a.LastChecked, &a.CreatedAt, &releaseGroups, &syncedAt,
+		&a.Name, &a.ImageURL, &genres, &a.Followers, &metadataUpdatedAt,
+	)
+	if err != nil {
+		return a, err
+	}
+	a.ReleaseGroups = splitList(releaseGroups)
+	a.SyncedAt = syncedAt.Time
+	a.MetadataUpdatedAt = metadataUpdatedAt.Time
+	if genres != "" {
+		if err := json.Unmarshal([]byte(genres), &a.Genres); err != nil {
+			return a, fmt.Errorf("invalid genres for artist %s: %w", a.SpotifyID, err)
+		}
+	}
+	return a, nil
+}
+
+// splitList splits a comma separated column, returning nil for an empty one
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// CachedResponse is a Spotify API response stored in the http_cache table
+type CachedResponse struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	StoredAt     time.Time
+	ExpiresAt    time.Time
+}
+
+// CacheStats summarizes the content of the http_cache table
+type CacheStats struct {
+	Entries   int   `json:"entries"`
+	SizeBytes int64 `json:"sizeBytes"`
+	Expired   int   `json:"expired"`
 }
 
 type SpotifyCredentials struct {
@@ -35,48 +125,65 @@ func New() (*Database, error) {
 		return nil, fmt.Errorf("failed to get config directory: %w", err)
 	}
 
-	// Create app directory if it doesn't exist
-	appDir := filepath.Join(configDir, "spotwrap-next")
-	if err := os.MkdirAll(appDir, 0755); err != nil {
+	return openDir(filepath.Join(configDir, "spotwrap-next"))
+}
+
+// openDir opens the database stored in appDir, creating it if needed
+func openDir(appDir string) (*Database, error) {
+	// Create app directory if it doesn't exist. It holds credentials, so
+	// only the user may read it, including when an older version created it.
+	if err := os.MkdirAll(appDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create app directory: %w", err)
 	}
+	if err := os.Chmod(appDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to restrict app directory permissions: %w", err)
+	}
+
+	key, err := loadKey(filepath.Join(appDir, keyFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
+	}
+	secrets, err := newSecretBox(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize encryption: %w", err)
+	}
 
 	dbPath := filepath.Join(appDir, "artists.db")
 
-	db, err := sql.Open("sqlite3", dbPath)
+	// The GUI and the --no-gui background process may use the database at the
+	// same time: WAL lets readers and a writer work concurrently, and
+	// immediate transactions take the write lock up front so they wait for
+	// each other instead of failing halfway through.
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=%d&_txlock=immediate",
+		dbPath, busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	db.SetMaxOpenConns(4)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxIdleTime(5 * time.Minute)
 
-	// Create artists table
-	createArtistsTableSQL := `
-	CREATE TABLE IF NOT EXISTS artists (
-		spotify_id TEXT PRIMARY KEY,
-		last_checked TIMESTAMP NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	if _, err := db.Exec(createArtistsTableSQL); err != nil {
+	// Bring the schema up to date, refusing databases from newer versions
+	if err := migrate(db, dbPath); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to create artists table: %w", err)
+		return nil, err
+	}
+	if err := os.Chmod(dbPath, 0600); err != nil {
+		log.Printf("Error restricting database permissions: %v", err)
 	}
 
-	// Create settings table for Spotify credentials
-	createSettingsTableSQL := `
-	CREATE TABLE IF NOT EXISTS settings (
-		key TEXT PRIMARY KEY,
-		value TEXT NOT NULL
-	);
-	`
-
-	if _, err := db.Exec(createSettingsTableSQL); err != nil {
+	d := &Database{db: db, secrets: secrets}
+	if err := d.encryptPlaintextSecrets(); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to create settings table: %w", err)
+		return nil, fmt.Errorf("failed to encrypt stored secrets: %w", err)
+	}
+	if _, err := d.PruneCache(); err != nil {
+		log.Printf("Error pruning response cache: %v", err)
 	}
 
 	fmt.Printf("Database initialized at %s\n", dbPath)
-	return &Database{db: db}, nil
+	return d, nil
 }
 
 // Close closes the database connection
@@ -87,7 +194,7 @@ func (d *Database) Close() error {
 
 // AddArtist adds or updates an artist in the database
 func (d *Database) AddArtist(spotifyID string) (bool, error) {
-	_, err := d.db.Exec(`
+	_, err := d.exec(`
 		INSERT INTO artists (spotify_id, last_checked)
 		VALUES (?, ?)
 		ON CONFLICT(spotify_id)
@@ -99,9 +206,15 @@ func (d *Database) AddArtist(spotifyID string) (bool, error) {
 	return true, nil
 }
 
-// RemoveArtist removes an artist from the database
+// RemoveArtist removes an artist and the releases seen for it from the database
 func (d *Database) RemoveArtist(spotifyID string) (bool, error) {
-	_, err := d.db.Exec("DELETE FROM artists WHERE spotify_id = ?", spotifyID)
+	err := d.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM artists WHERE spotify_id = ?", spotifyID); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM releases WHERE artist_id = ?", spotifyID)
+		return err
+	})
 	if err != nil {
 		return false, err
 	}
@@ -110,7 +223,7 @@ func (d *Database) RemoveArtist(spotifyID string) (bool, error) {
 
 // GetArtists retrieves all subscribed artists
 func (d *Database) GetArtistsFromDB() ([]Artist, error) {
-	rows, err := d.db.Query("SELECT spotify_id, last_checked, created_at FROM artists")
+	rows, err := d.db.Query("SELECT " + artistColumns + " FROM artists")
 	if err != nil {
 		return nil, err
 	}
@@ -118,8 +231,8 @@ func (d *Database) GetArtistsFromDB() ([]Artist, error) {
 
 	var artists []Artist
 	for rows.Next() {
-		var a Artist
-		if err := rows.Scan(&a.SpotifyID, &a.LastChecked, &a.CreatedAt); err != nil {
+		a, err := scanArtist(rows)
+		if err != nil {
 			return nil, err
 		}
 		artists = append(artists, a)
@@ -132,42 +245,139 @@ func (d *Database) GetArtistsFromDB() ([]Artist, error) {
 }
 
 func (d *Database) GetArtistByID(id string) (*Artist, error) {
-	row := d.db.QueryRow("SELECT spotify_id, last_checked, created_at FROM artists WHERE spotify_id = ?", id)
-	var a Artist
-	if err := row.Scan(&a.SpotifyID, &a.LastChecked, &a.CreatedAt); err != nil {
+	row := d.db.QueryRow("SELECT "+artistColumns+" FROM artists WHERE spotify_id = ?", id)
+	a, err := scanArtist(row)
+	if err != nil {
 		return nil, err
 	}
 	return &a, nil
 }
 
-// StoreSpotifyCredentials saves Spotify API credentials to the database
-func (d *Database) StoreSpotifyCredentials(clientID, clientSecret string) error {
-	tx, err := d.db.Begin()
+// UpdateArtistMetadata stores the Spotify metadata of a subscribed artist
+func (d *Database) UpdateArtistMetadata(spotifyID string, meta ArtistMetadata) error {
+	genres, err := json.Marshal(meta.Genres)
 	if err != nil {
 		return err
 	}
+	if meta.Genres == nil {
+		genres = []byte("[]")
+	}
+
+	_, err = d.exec(`
+		UPDATE artists SET name = ?, image_url = ?, genres = ?, follower_count = ?, metadata_updated_at = ?
+		WHERE spotify_id = ?`,
+		meta.Name, meta.ImageURL, string(genres), meta.Followers, time.Now(), spotifyID)
+	return err
+}
 
-	// Store client ID
-	_, err = tx.Exec(
-		"INSERT INTO settings (key, value) VALUES ('spotify_client_id', ?) ON CONFLICT(key) DO UPDATE SET value = ?",
-		clientID, clientID,
+// SetArtistReleaseGroups sets the release groups checked for an artist.
+// An empty list falls back to the global default.
+func (d *Database) SetArtistReleaseGroups(spotifyID string, groups []string) error {
+	res, err := d.exec(
+		"UPDATE artists SET release_groups = ? WHERE spotify_id = ?",
+		strings.Join(groups, ","), spotifyID,
 	)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("artist %s is not subscribed", spotifyID)
+	}
+	return nil
+}
 
-	// Store client secret
-	_, err = tx.Exec(
-		"INSERT INTO settings (key, value) VALUES ('spotify_client_secret', ?) ON CONFLICT(key) DO UPDATE SET value = ?",
-		clientSecret, clientSecret,
+// AddRelease records a release as seen. It returns true when the release
+// had not been seen before for this artist.
+func (d *Database) AddRelease(r Release) (bool, error) {
+	res, err := d.exec(`
+		INSERT OR IGNORE INTO releases (album_id, artist_id, name, type, release_date, first_seen, notified)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.AlbumID, r.ArtistID, r.Name, r.Type, r.ReleaseDate, r.FirstSeen, r.Notified)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// IsReleaseSeen reports whether a release was already recorded for an artist
+func (d *Database) IsReleaseSeen(albumID, artistID string) (bool, error) {
+	var exists bool
+	err := d.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM releases WHERE album_id = ? AND artist_id = ?)",
+		albumID, artistID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// PendingReleases returns the releases of an artist the user has not been
+// notified about yet, oldest first
+func (d *Database) PendingReleases(artistID string) ([]Release, error) {
+	rows, err := d.db.Query(`
+		SELECT album_id, artist_id, name, type, release_date, first_seen, notified
+		FROM releases WHERE artist_id = ? AND notified = 0
+		ORDER BY first_seen`, artistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []Release
+	for rows.Next() {
+		var r Release
+		if err := rows.Scan(&r.AlbumID, &r.ArtistID, &r.Name, &r.Type, &r.ReleaseDate, &r.FirstSeen, &r.Notified); err != nil {
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return releases, rows.Err()
+}
+
+// MarkReleaseNotified records that the user was notified about a release
+func (d *Database) MarkReleaseNotified(albumID, artistID string) error {
+	_, err := d.exec(
+		"UPDATE releases SET notified = 1 WHERE album_id = ? AND artist_id = ?",
+		albumID, artistID,
 	)
+	return err
+}
+
+// MarkArtistSynced records that the releases of an artist have been seeded
+func (d *Database) MarkArtistSynced(spotifyID string) error {
+	_, err := d.exec(
+		"UPDATE artists SET releases_synced_at = ? WHERE spotify_id = ? AND releases_synced_at IS NULL",
+		time.Now(), spotifyID,
+	)
+	return err
+}
+
+// StoreSpotifyCredentials saves Spotify API credentials to the database
+func (d *Database) StoreSpotifyCredentials(clientID, clientSecret string) error {
+	clientSecret, err := d.encryptSecret("spotify_client_secret", clientSecret)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	return tx.Commit()
+	return d.withTx(func(tx *sql.Tx) error {
+		// Store client ID
+		_, err := tx.Exec(
+			"INSERT INTO settings (key, value) VALUES ('spotify_client_id', ?) ON CONFLICT(key) DO UPDATE SET value = ?",
+			clientID, clientID,
+		)
+		if err != nil {
+			return err
+		}
+
+		// Store client secret
+		_, err = tx.Exec(
+			"INSERT INTO settings (key, value) VALUES ('spotify_client_secret', ?) ON CONFLICT(key) DO UPDATE SET value = ?",
+			clientSecret, clientSecret,
+		)
+		return err
+	})
 }
 
 // GetSpotifyCredentials retrieves Spotify API credentials from the database
@@ -185,21 +395,25 @@ func (d *Database) GetSpotifyCredentials() (SpotifyCredentials, error) {
 	}
 
 	// Get client secret
-	var clientSecret sql.NullString
-	err = d.db.QueryRow("SELECT value FROM settings WHERE key = 'spotify_client_secret'").Scan(&clientSecret)
-	if err != nil && err != sql.ErrNoRows {
+	creds.ClientSecret, err = d.GetSecret("spotify_client_secret")
+	if err != nil {
 		return creds, err
 	}
-	if clientSecret.Valid {
-		creds.ClientSecret = clientSecret.String
-	}
 
 	return creds, nil
 }
 
 // SetSetting saves a key-value pair to the settings table.
+// Secrets are encrypted before being stored.
 func (d *Database) SetSetting(key string, value string) error {
-	_, err := d.db.Exec(
+	if IsSecretKey(key) && value != "" {
+		var err error
+		if value, err = d.encryptSecret(key, value); err != nil {
+			return err
+		}
+	}
+
+	_, err := d.exec(
 		"INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = ?",
 		key, value, value,
 	)
@@ -208,7 +422,16 @@ func (d *Database) SetSetting(key string, value string) error {
 
 // GetSetting retrieves a value from the settings table by its key.
 // It returns an empty string and no error if the key is not found.
+// Secrets are only available through GetSecret.
 func (d *Database) GetSetting(key string) (string, error) {
+	if IsSecretKey(key) {
+		return "", fmt.Errorf("%w: %s", ErrSecretSetting, key)
+	}
+	return d.getSettingValue(key)
+}
+
+// getSettingValue returns the stored value of a setting, "" if it is not set
+func (d *Database) getSettingValue(key string) (string, error) {
 	var val sql.NullString
 	err := d.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&val)
 	if err != nil {
@@ -222,3 +445,114 @@ func (d *Database) GetSetting(key string) (string, error) {
 	}
 	return "", nil // Should not happen if ErrNoRows is handled, but as a fallback
 }
+
+// GetCachedResponse returns the cached response stored under key, or nil if there is none
+func (d *Database) GetCachedResponse(key string) (*CachedResponse, error) {
+	var r CachedResponse
+	err := d.db.QueryRow(
+		"SELECT body, etag, last_modified, stored_at, expires_at FROM http_cache WHERE key = ?",
+		key,
+	).Scan(&r.Body, &r.ETag, &r.LastModified, &r.StoredAt, &r.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+// PutCachedResponse stores a response under key, replacing any previous one
+func (d *Database) PutCachedResponse(key string, r CachedResponse) error {
+	_, err := d.exec(`
+		INSERT INTO http_cache (key, body, etag, last_modified, stored_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			body = excluded.body,
+			etag = excluded.etag,
+			last_modified = excluded.last_modified,
+			stored_at = excluded.stored_at,
+			expires_at = excluded.expires_at`,
+		key, r.Body, r.ETag, r.LastModified, r.StoredAt, r.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	if d.cacheWrites.Add(1) >= cachePruneInterval {
+		d.cacheWrites.Store(0)
+		if _, err := d.PruneCache(); err != nil {
+			log.Printf("Error pruning response cache: %v", err)
+		}
+	}
+	return nil
+}
+
+// Bounds of the http_cache table
+const (
+	// maxCacheEntries caps the number of cached responses, the least
+	// recently stored ones are dropped first
+	maxCacheEntries = 5000
+	// cacheRevalidateWindow is how long an expired response carrying an ETag
+	// or Last-Modified is kept, so that it can still be revalidated
+	cacheRevalidateWindow = 7 * 24 * time.Hour
+	// cachePruneInterval is the number of writes between two prunes
+	cachePruneInterval = 200
+)
+
+// PruneCache deletes the cached responses that can no longer be used: the
+// expired ones without validators, the ones expired for longer than
+// cacheRevalidateWindow, and the oldest ones beyond maxCacheEntries. It
+// returns the number of responses deleted.
+func (d *Database) PruneCache() (int64, error) {
+	now := time.Now()
+	var deleted int64
+	err := d.withTx(func(tx *sql.Tx) error {
+		deleted = 0
+		res, err := tx.Exec(`
+			DELETE FROM http_cache
+			WHERE (expires_at < ? AND etag = '' AND last_modified = '') OR expires_at < ?`,
+			now, now.Add(-cacheRevalidateWindow))
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		deleted += n
+
+		res, err = tx.Exec(`
+			DELETE FROM http_cache WHERE key NOT IN (
+				SELECT key FROM http_cache ORDER BY stored_at DESC LIMIT ?
+			)`,
+			maxCacheEntries)
+		if err != nil {
+			return err
+		}
+		n, err = res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		deleted += n
+		return nil
+	})
+	return deleted, err
+}
+
+// GetCacheStats returns the number of cached responses and their total size
+func (d *Database) GetCacheStats() (CacheStats, error) {
+	var stats CacheStats
+	err := d.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(LENGTH(body)), 0),
+			COALESCE(SUM(CASE WHEN expires_at < ? THEN 1 ELSE 0 END), 0)
+		FROM http_cache`,
+		time.Now(),
+	).Scan(&stats.Entries, &stats.SizeBytes, &stats.Expired)
+	return stats, err
+}
+
+// ClearCache deletes every cached response
+func (d *Database) ClearCache() error {
+	_, err := d.exec("DELETE FROM http_cache")
+	return err
+}
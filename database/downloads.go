@@ -0,0 +1,194 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Download is one run of the downloader recorded in the downloads table
+type Download struct {
+	ID         int64
+	Link       string // link as entered by the user
+	Kind       string // track, album, playlist or artist, empty if the link could not be resolved
+	SpotifyID  string
+	OutputPath string
+	Format     string
+	Bitrate    string
+	StartedAt  time.Time
+	FinishedAt time.Time // zero while the download is running
+	Status     string
+	Error      string
+	Files      []string // files written by the download
+}
+
+// DownloadFilter selects entries of the download history. Zero fields match everything.
+type DownloadFilter struct {
+	Status string
+	Kind   string
+	Search string // substring of the link or Spotify ID
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Offset int
+}
+
+// downloadColumns lists the downloads columns in the order scanDownload expects them
+const downloadColumns = "id, link, kind, spotify_id, output_path, format, bitrate, " +
+	"started_at, finished_at, status, error, files"
+
+// scanDownload reads a download selected with downloadColumns
+func scanDownload(row rowScanner) (Download, error) {
+	var dl Download
+	var finishedAt sql.NullTime
+	var files string
+	err := row.Scan(
+		&dl.ID, &dl.Link, &dl.Kind, &dl.SpotifyID, &dl.OutputPath, &dl.Format, &dl.Bitrate,
+		&dl.StartedAt, &finishedAt, &dl.Status, &dl.Error, &files,
+	)
+	if err != nil {
+		return dl, err
+	}
+	dl.FinishedAt = finishedAt.Time
+	if err := json.Unmarshal([]byte(files), &dl.Files); err != nil {
+		return dl, fmt.Errorf("invalid files for download %d: %w", dl.ID, err)
+	}
+	return dl, nil
+}
+
+// marshalFiles encodes a file list for the files column
+func marshalFiles(files []string) (string, error) {
+	if files == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(files)
+	return string(data), err
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// AddDownload records a download and returns its ID
+func (d *Database) AddDownload(dl Download) (int64, error) {
+	files, err := marshalFiles(dl.Files)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := d.exec(`
+		INSERT INTO downloads (link, kind, spotify_id, output_path, format, bitrate, started_at, finished_at, status, error, files)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		dl.Link, dl.Kind, dl.SpotifyID, dl.OutputPath, dl.Format, dl.Bitrate,
+		dl.StartedAt, nullTime(dl.FinishedAt), dl.Status, dl.Error, files)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateDownload saves the outcome of a recorded download
+func (d *Database) UpdateDownload(dl Download) error {
+	files, err := marshalFiles(dl.Files)
+	if err != nil {
+		return err
+	}
+
+	res, err := d.exec(`
+		UPDATE downloads SET kind = ?, spotify_id = ?, finished_at = ?, status = ?, error = ?, files = ?
+		WHERE id = ?`,
+		dl.Kind, dl.SpotifyID, nullTime(dl.FinishedAt), dl.Status, dl.Error, files, dl.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("download %d not found", dl.ID)
+	}
+	return nil
+}
+
+// GetDownloads returns the downloads matching filter, most recent first
+func (d *Database) GetDownloads(filter DownloadFilter) ([]Download, error) {
+	var where []string
+	var args []any
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Kind != "" {
+		where = append(where, "kind = ?")
+		args = append(args, filter.Kind)
+	}
+	if filter.Search != "" {
+		where = append(where, "(link LIKE ? ESCAPE '\\' OR spotify_id = ?)")
+		args = append(args, "%"+escapeLike(filter.Search)+"%", filter.Search)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "started_at < ?")
+		args = append(args, filter.Until)
+	}
+
+	query := "SELECT " + downloadColumns + " FROM downloads"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY started_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	downloads := make([]Download, 0)
+	for rows.Next() {
+		dl, err := scanDownload(rows)
+		if err != nil {
+			return nil, err
+		}
+		downloads = append(downloads, dl)
+	}
+	return downloads, rows.Err()
+}
+
+// GetDownload returns a recorded download, or nil if there is none with this ID
+func (d *Database) GetDownload(id int64) (*Download, error) {
+	row := d.db.QueryRow("SELECT "+downloadColumns+" FROM downloads WHERE id = ?", id)
+	dl, err := scanDownload(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dl, nil
+}
+
+// DeleteDownload removes an entry from the download history. The downloaded files are kept.
+func (d *Database) DeleteDownload(id int64) error {
+	_, err := d.exec("DELETE FROM downloads WHERE id = ?", id)
+	return err
+}
+
+// ClearDownloads removes every finished entry from the download history
+func (d *Database) ClearDownloads() error {
+	_, err := d.exec("DELETE FROM downloads WHERE finished_at IS NOT NULL")
+	return err
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,365 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ExportVersion is the version of the export document written by Export.
+// Import reads every version up to this one.
+const ExportVersion = 1
+
+// Import modes
+const (
+	// ImportMerge adds what is missing and keeps existing values on conflict
+	ImportMerge = "merge"
+	// ImportReplace makes the subscriptions and settings match the document
+	ImportReplace = "replace"
+)
+
+// portableSettings are the settings Export writes and Import applies. The
+// others, such as the executable paths, the Spotify endpoints, the number of
+// parallel downloads and the last download path, only make sense on the
+// machine they were set on.
+var portableSettings = map[string]bool{
+	"spotify_client_id":       true,
+	"appendArtistAlbumToPath": true,
+	"market":                  true,
+	"default_release_groups":  true,
+}
+
+// credentialPairs maps a setting to the secret that only works with it, so
+// that Import keeps or replaces the two as a whole
+var credentialPairs = map[string]string{
+	"spotify_client_id": "spotify_client_secret",
+}
+
+// ExportDocument is the portable form of the subscriptions and settings
+type ExportDocument struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Artists    []ExportedArtist  `json:"artists"`
+	Settings   map[string]string `json:"settings"`
+	Secrets    map[string]string `json:"secrets,omitempty"` // plaintext, only when asked for
+}
+
+// ExportedArtist is a subscription in an ExportDocument
+type ExportedArtist struct {
+	SpotifyID     string    `json:"spotifyId"`
+	Name          string    `json:"name,omitempty"` // informative, refreshed from Spotify after import
+	ReleaseGroups []string  `json:"releaseGroups,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// ImportConflict is a value of the document that differs from the stored one
+type ImportConflict struct {
+	Kind     string `json:"kind"` // artist, setting or secret
+	Key      string `json:"key"`
+	Existing string `json:"existing"`
+	Imported string `json:"imported"`
+	Resolved string `json:"resolved"` // kept or replaced
+}
+
+// ImportReport describes what Import changed
+type ImportReport struct {
+	ArtistsAdded    int              `json:"artistsAdded"`
+	ArtistsUpdated  int              `json:"artistsUpdated"`
+	ArtistsRemoved  int              `json:"artistsRemoved"`
+	SettingsChanged int              `json:"settingsChanged"`
+	Conflicts       []ImportConflict `json:"conflicts"`
+}
+
+// Export returns the subscriptions and portable settings. Secrets are
+// decrypted into the document only when includeSecrets is set.
+func (d *Database) Export(includeSecrets bool) (*ExportDocument, error) {
+	artists, err := d.GetArtistsFromDB()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &ExportDocument{
+		Version:    ExportVersion,
+		ExportedAt: time.Now(),
+		Artists:    make([]ExportedArtist, 0, len(artists)),
+		Settings:   make(map[string]string),
+	}
+	for _, a := range artists {
+		doc.Artists = append(doc.Artists, ExportedArtist{
+			SpotifyID:     a.SpotifyID,
+			Name:          a.Name,
+			ReleaseGroups: a.ReleaseGroups,
+			CreatedAt:     a.CreatedAt,
+		})
+	}
+
+	settings, err := allSettings(d.db)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range settings {
+		if portableSettings[key] {
+			doc.Settings[key] = value
+		}
+	}
+
+	if includeSecrets {
+		doc.Secrets = make(map[string]string)
+		for key := range secretKeys {
+			value, err := d.GetSecret(key)
+			if err != nil {
+				return nil, err
+			}
+			if value != "" {
+				doc.Secrets[key] = value
+			}
+		}
+	}
+	return doc, nil
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// allSettings returns every stored setting, secrets still encrypted
+func allSettings(q querier) (map[string]string, error) {
+	rows, err := q.Query("SELECT key, value FROM settings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+	return settings, rows.Err()
+}
+
+// artistReleaseGroups returns the comma separated release groups of every
+// subscribed artist, by Spotify ID
+func artistReleaseGroups(q querier) (map[string]string, error) {
+	rows, err := q.Query("SELECT spotify_id, release_groups FROM artists")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	artists := make(map[string]string)
+	for rows.Next() {
+		var id, groups string
+		if err := rows.Scan(&id, &groups); err != nil {
+			return nil, err
+		}
+		artists[id] = strings.Join(splitList(groups), ",")
+	}
+	return artists, rows.Err()
+}
+
+// Import applies an export document in a single transaction. In merge mode
+// existing values win over the document; in replace mode the document wins
+// and subscriptions and portable settings missing from it are removed.
+// Settings that are not portable are ignored and left as they are. Secrets
+// are only touched when the document carries them or when the setting they
+// belong to changes. The caller validates the values of the document.
+func (d *Database) Import(doc *ExportDocument, mode string) (ImportReport, error) {
+	var report ImportReport
+	if doc.Version < 1 || doc.Version > ExportVersion {
+		return report, fmt.Errorf("unsupported export version %d, this version reads up to %d", doc.Version, ExportVersion)
+	}
+	if mode != ImportMerge && mode != ImportReplace {
+		return report, fmt.Errorf("invalid import mode %q", mode)
+	}
+
+	for _, a := range doc.Artists {
+		if a.SpotifyID == "" {
+			return report, errors.New("artist without spotifyId in export")
+		}
+	}
+
+	// Encrypt the secrets before taking the write lock
+	secrets := make(map[string]string, len(doc.Secrets))
+	for key, value := range doc.Secrets {
+		if !IsSecretKey(key) {
+			return report, fmt.Errorf("unknown secret %q", key)
+		}
+		var err error
+		if secrets[key], err = d.encryptSecret(key, value); err != nil {
+			return report, err
+		}
+	}
+
+	err := d.withTx(func(tx *sql.Tx) error {
+		// withTx retries on a busy database, start from a clean report each time
+		report = ImportReport{Conflicts: make([]ImportConflict, 0)}
+
+		// Read the current state in the transaction, so that no write of the
+		// other process slips in between
+		artists, err := artistReleaseGroups(tx)
+		if err != nil {
+			return err
+		}
+		settings, err := allSettings(tx)
+		if err != nil {
+			return err
+		}
+
+		imported := make(map[string]bool, len(doc.Artists))
+		for _, a := range doc.Artists {
+			imported[a.SpotifyID] = true
+			groups := strings.Join(a.ReleaseGroups, ",")
+
+			existingGroups, ok := artists[a.SpotifyID]
+			if !ok {
+				createdAt := a.CreatedAt
+				if createdAt.IsZero() {
+					createdAt = time.Now()
+				}
+				// releases_synced_at stays NULL: the next check seeds the releases without notifying
+				_, err := tx.Exec(
+					"INSERT INTO artists (spotify_id, last_checked, created_at, release_groups, name) VALUES (?, ?, ?, ?, ?)",
+					a.SpotifyID, time.Now(), createdAt, groups, a.Name,
+				)
+				if err != nil {
+					return err
+				}
+				report.ArtistsAdded++
+				continue
+			}
+
+			if existingGroups == groups {
+				continue
+			}
+			conflict := ImportConflict{
+				Kind:     "artist",
+				Key:      a.SpotifyID,
+				Existing: existingGroups,
+				Imported: groups,
+				Resolved: "kept",
+			}
+			if mode == ImportReplace {
+				if _, err := tx.Exec("UPDATE artists SET release_groups = ? WHERE spotify_id = ?", groups, a.SpotifyID); err != nil {
+					return err
+				}
+				conflict.Resolved = "replaced"
+				report.ArtistsUpdated++
+			}
+			report.Conflicts = append(report.Conflicts, conflict)
+		}
+
+		if mode == ImportReplace {
+			for id := range artists {
+				if imported[id] {
+					continue
+				}
+				if _, err := tx.Exec("DELETE FROM artists WHERE spotify_id = ?", id); err != nil {
+					return err
+				}
+				if _, err := tx.Exec("DELETE FROM releases WHERE artist_id = ?", id); err != nil {
+					return err
+				}
+				report.ArtistsRemoved++
+			}
+		}
+
+		values := make(map[string]string, len(doc.Settings)+len(secrets))
+		for key, value := range doc.Settings {
+			if IsSecretKey(key) {
+				return fmt.Errorf("secret %q found in settings", key)
+			}
+			if portableSettings[key] {
+				values[key] = value
+			}
+		}
+		for key, value := range secrets {
+			values[key] = value
+		}
+
+		// A stored secret is useless without the setting it belongs to
+		var staleSecrets []string
+		for key, secret := range credentialPairs {
+			existing := settings[key]
+			if existing == "" || existing == values[key] {
+				continue
+			}
+			if mode == ImportMerge {
+				// The stored setting is kept, and so is its secret
+				delete(values, secret)
+			} else if _, ok := values[secret]; !ok {
+				staleSecrets = append(staleSecrets, secret)
+			}
+		}
+
+		for key, value := range values {
+			existing, ok := settings[key]
+			if ok && existing != "" {
+				same := existing == value
+				if IsSecretKey(key) {
+					plain, err := d.decryptSecret(key, existing)
+					same = err == nil && plain == doc.Secrets[key]
+				}
+				if same {
+					continue
+				}
+				conflict := ImportConflict{Kind: "setting", Key: key, Existing: existing, Imported: value, Resolved: "kept"}
+				if IsSecretKey(key) {
+					// Never put secrets in the report
+					conflict = ImportConflict{Kind: "secret", Key: key, Resolved: "kept"}
+				}
+				if mode == ImportMerge {
+					report.Conflicts = append(report.Conflicts, conflict)
+					continue
+				}
+				conflict.Resolved = "replaced"
+				report.Conflicts = append(report.Conflicts, conflict)
+			}
+			_, err := tx.Exec(
+				"INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = ?",
+				key, value, value,
+			)
+			if err != nil {
+				return err
+			}
+			report.SettingsChanged++
+		}
+
+		if mode == ImportReplace {
+			for key := range settings {
+				if _, ok := values[key]; ok || !portableSettings[key] {
+					continue
+				}
+				if _, err := tx.Exec("DELETE FROM settings WHERE key = ?", key); err != nil {
+					return err
+				}
+				report.SettingsChanged++
+			}
+		}
+		for _, key := range staleSecrets {
+			if _, ok := settings[key]; !ok {
+				continue
+			}
+			if _, err := tx.Exec("DELETE FROM settings WHERE key = ?", key); err != nil {
+				return err
+			}
+			report.SettingsChanged++
+		}
+		return nil
+	})
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	slices.SortFunc(report.Conflicts, func(a, b ImportConflict) int {
+		return strings.Compare(a.Kind+"/"+a.Key, b.Kind+"/"+b.Key)
+	})
+	return report, nil
+}
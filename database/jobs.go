@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// DownloadJob is an entry of the persistent download queue
+type DownloadJob struct {
+	ID         int64
+	Link       string
+	OutputPath string
+	Format     string
+	Bitrate    string
+	Priority   int
+	Status     string
+	Error      string
+	Attempts   int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// AddDownloadJob stores a new job and returns its ID
+func (d *Database) AddDownloadJob(job DownloadJob) (int64, error) {
+	res, err := d.exec(`
+		INSERT INTO download_jobs (link, output_path, format, bitrate, priority, status, error, attempts, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.Link, job.OutputPath, job.Format, job.Bitrate, job.Priority,
+		job.Status, job.Error, job.Attempts, job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateDownloadJob saves the state of a job
+func (d *Database) UpdateDownloadJob(job DownloadJob) error {
+	res, err := d.exec(`
+		UPDATE download_jobs SET priority = ?, status = ?, error = ?, attempts = ?, updated_at = ?
+		WHERE id = ?`,
+		job.Priority, job.Status, job.Error, job.Attempts, job.UpdatedAt, job.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("download job %d not found", job.ID)
+	}
+	return nil
+}
+
+// DeleteDownloadJob removes a job from the download queue
+func (d *Database) DeleteDownloadJob(id int64) error {
+	_, err := d.exec("DELETE FROM download_jobs WHERE id = ?", id)
+	return err
+}
+
+// GetDownloadJobs returns every job of the download queue in creation order
+func (d *Database) GetDownloadJobs() ([]DownloadJob, error) {
+	rows, err := d.db.Query(`
+		SELECT id, link, output_path, format, bitrate, priority, status, error, attempts, created_at, updated_at
+		FROM download_jobs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]DownloadJob, 0)
+	for rows.Next() {
+		var job DownloadJob
+		err := rows.Scan(
+			&job.ID, &job.Link, &job.OutputPath, &job.Format, &job.Bitrate, &job.Priority,
+			&job.Status, &job.Error, &job.Attempts, &job.CreatedAt, &job.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
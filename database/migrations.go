@@ -0,0 +1,329 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when the database was written by a newer version of the app
+var ErrSchemaTooNew = errors.New("database was created by a newer version of the app")
+
+// migration is one step of the schema history. Migrations are applied in
+// order, each in its own transaction, and must never be edited once
+// released: add a new one instead.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// migrations is the ordered schema history of the database
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create artists and settings tables",
+		up: execStatements(`
+		CREATE TABLE IF NOT EXISTS artists (
+			spotify_id TEXT PRIMARY KEY,
+			last_checked TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`, `
+		CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		);`),
+	},
+	{
+		version: 2,
+		name:    "create http_cache table",
+		up: execStatements(`
+		CREATE TABLE IF NOT EXISTS http_cache (
+			key TEXT PRIMARY KEY,
+			body BLOB NOT NULL,
+			etag TEXT NOT NULL DEFAULT '',
+			last_modified TEXT NOT NULL DEFAULT '',
+			stored_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL
+		);`),
+	},
+	{
+		version: 3,
+		name:    "add release groups to artists",
+		up: func(tx *sql.Tx) error {
+			return addColumnIfMissing(tx, "artists", "release_groups", "TEXT NOT NULL DEFAULT ''")
+		},
+	},
+	{
+		version: 4,
+		name:    "create releases table",
+		up: func(tx *sql.Tx) error {
+			if err := addColumnIfMissing(tx, "artists", "releases_synced_at", "TIMESTAMP"); err != nil {
+				return err
+			}
+			return execStatements(`
+			CREATE TABLE IF NOT EXISTS releases (
+				album_id TEXT NOT NULL,
+				artist_id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				type TEXT NOT NULL,
+				release_date TEXT NOT NULL DEFAULT '',
+				first_seen TIMESTAMP NOT NULL,
+				notified INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (album_id, artist_id)
+			);`)(tx)
+		},
+	},
+	{
+		version: 5,
+		name:    "add cached metadata to artists",
+		up: func(tx *sql.Tx) error {
+			columns := []struct{ name, definition string }{
+				{"name", "TEXT NOT NULL DEFAULT ''"},
+				{"image_url", "TEXT NOT NULL DEFAULT ''"},
+				{"genres", "TEXT NOT NULL DEFAULT ''"}, // JSON array
+				{"follower_count", "INTEGER NOT NULL DEFAULT 0"},
+				{"metadata_updated_at", "TIMESTAMP"},
+			}
+			for _, col := range columns {
+				if err := addColumnIfMissing(tx, "artists", col.name, col.definition); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 6,
+		name:    "create downloads table",
+		up: execStatements(`
+		CREATE TABLE IF NOT EXISTS downloads (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			link TEXT NOT NULL,
+			kind TEXT NOT NULL DEFAULT '',
+			spotify_id TEXT NOT NULL DEFAULT '',
+			output_path TEXT NOT NULL DEFAULT '',
+			format TEXT NOT NULL DEFAULT '',
+			bitrate TEXT NOT NULL DEFAULT '',
+			started_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			files TEXT NOT NULL DEFAULT '[]' -- JSON array
+		);`, `
+		CREATE INDEX IF NOT EXISTS downloads_started_at ON downloads (started_at);`),
+	},
+	{
+		version: 7,
+		name:    "create download_jobs table",
+		up: execStatements(`
+		CREATE TABLE IF NOT EXISTS download_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			link TEXT NOT NULL,
+			output_path TEXT NOT NULL DEFAULT '',
+			format TEXT NOT NULL DEFAULT '',
+			bitrate TEXT NOT NULL DEFAULT '',
+			priority INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`),
+	},
+	{
+		// notified = 0 now means a notification is pending. Earlier
+		// versions left it at 0 for seeded and already reported releases.
+		version: 8,
+		name:    "mark recorded releases as notified",
+		up:      execStatements(`UPDATE releases SET notified = 1;`),
+	},
+}
+
+// execStatements returns a migration step running each statement in order
+func execStatements(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, stmt := range statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// schemaVersion returns the latest applied migration, 0 for a new database
+func schemaVersion(db *sql.DB) (int, error) {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	);
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// migrate brings the database at dbPath up to the latest schema. The
+// database file is backed up before the first pending migration runs.
+func migrate(db *sql.DB, dbPath string) error {
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("%w: schema version %d, this version supports up to %d", ErrSchemaTooNew, current, latest)
+	}
+	if current == latest {
+		return nil
+	}
+
+	// Databases from before the migration system have tables but no version
+	hasData, err := hasUserTables(db)
+	if err != nil {
+		return err
+	}
+	if hasData {
+		backupPath, err := backupDatabase(db, dbPath, current)
+		if err != nil {
+			return fmt.Errorf("failed to back up database before migrating: %w", err)
+		}
+		log.Printf("Database backed up to %s", backupPath)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		var applied bool
+		err := retryOnBusy(func() error {
+			var err error
+			applied, err = applyMigration(db, m)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		if applied {
+			log.Printf("Applied database migration %d: %s", m.version, m.name)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs m and records it in a single transaction. The version
+// is checked again inside the transaction, which holds the write lock, so a
+// migration applied meanwhile by another process is skipped.
+func applyMigration(db *sql.DB, m migration) (applied bool, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil || !applied {
+			tx.Rollback()
+		}
+	}()
+
+	var done bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)", m.version).Scan(&done); err != nil {
+		return false, err
+	}
+	if done {
+		return false, nil
+	}
+
+	if err := m.up(tx); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		m.version, m.name, time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// hasUserTables reports whether the database holds any table besides schema_migrations
+func hasUserTables(db *sql.DB) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'`,
+	).Scan(&count)
+	return count > 0, err
+}
+
+// backupDatabase writes a consistent copy of the database next to it and returns its path
+func backupDatabase(db *sql.DB, dbPath string, version int) (string, error) {
+	backupPath := fmt.Sprintf("%s.v%d-%s.bak", dbPath, version, time.Now().Format("20060102-150405"))
+	// Another process starting at the same time may have just made it
+	if _, err := os.Stat(backupPath); err == nil {
+		return backupPath, nil
+	}
+
+	if _, err := db.Exec("VACUUM INTO ?", backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// execQuerier is implemented by *sql.DB and *sql.Tx
+type execQuerier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already
+// there, so that migrations also work on databases that had it added by hand
+func addColumnIfMissing(db execQuerier, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
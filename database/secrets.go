@@ -0,0 +1,153 @@
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrSecretSetting is returned when a secret is requested through GetSetting
+var ErrSecretSetting = errors.New("setting is a secret")
+
+// secretKeys are the settings stored encrypted
+var secretKeys = map[string]bool{
+	"spotify_client_secret": true,
+}
+
+// IsSecretKey reports whether the setting key holds a secret
+func IsSecretKey(key string) bool {
+	return secretKeys[key]
+}
+
+// SecretKeys returns the settings stored encrypted
+func SecretKeys() []string {
+	keys := make([]string, 0, len(secretKeys))
+	for key := range secretKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+const (
+	// secretPrefix marks encrypted values, anything else is legacy plaintext
+	secretPrefix = "enc:v1:"
+	// keyFileName is the file next to the database holding the encryption key
+	keyFileName = "secret.key"
+	keySize     = 32
+)
+
+// loadKey reads the encryption key at path, creating it on first use. The
+// key never enters the database, so a copy of artists.db alone doesn't leak
+// the secrets.
+func loadKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("invalid key file %s", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key = make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	// O_EXCL so that two processes starting together agree on one key
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return loadKey(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return key, nil
+}
+
+// newSecretBox returns the AES-GCM cipher encrypting secrets with key
+func newSecretBox(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSecret encrypts the value of a secret setting. The setting key is
+// authenticated too, so a value can't be moved to another key.
+func (d *Database) encryptSecret(key, value string) (string, error) {
+	nonce := make([]byte, d.secrets.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := d.secrets.Seal(nonce, nonce, []byte(value), []byte(key))
+	return secretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret decrypts a stored secret, returning legacy plaintext values as is
+func (d *Database) decryptSecret(key, stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, secretPrefix)
+	if !ok {
+		return stored, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret %s: %w", key, err)
+	}
+	nonceSize := d.secrets.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("invalid secret %s: too short", key)
+	}
+	plain, err := d.secrets.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s, was the key file replaced? %w", key, err)
+	}
+	return string(plain), nil
+}
+
+// GetSecret returns the decrypted value of a secret setting, or an empty
+// string if it is not set
+func (d *Database) GetSecret(key string) (string, error) {
+	stored, err := d.getSettingValue(key)
+	if err != nil || stored == "" {
+		return "", err
+	}
+	return d.decryptSecret(key, stored)
+}
+
+// encryptPlaintextSecrets encrypts secrets written by versions without encryption
+func (d *Database) encryptPlaintextSecrets() error {
+	for key := range secretKeys {
+		stored, err := d.getSettingValue(key)
+		if err != nil {
+			return err
+		}
+		if stored == "" || strings.HasPrefix(stored, secretPrefix) {
+			continue
+		}
+		if err := d.SetSetting(key, stored); err != nil {
+			return err
+		}
+		log.Printf("Encrypted setting %s", key)
+	}
+	return nil
+}
@@ -7,29 +7,78 @@ export interface DownloadState {
   isDownloading: boolean;
 }
 
+// DownloadEvent mirrors spotdl.Event, emitted as download_event
+interface DownloadEvent {
+  jobId: number;
+  type: string;
+  link?: string;
+  track?: string;
+  reason?: string;
+  total?: number;
+  status?: string;
+  line?: string;
+}
+
 export const useDownloadStore = defineStore("download", () => {
   const downloadMessages = ref<string[]>([]);
   const isDownloading = ref(false);
+  // Jobs started and not finished yet
+  const runningJobs = new Set<number>();
+  let listening = false;
+
+  // message turns a download event into a line of the download log
+  function message(event: DownloadEvent): string | null {
+    switch (event.type) {
+      case "job_started":
+        return `Downloading ${event.link}`;
+      case "track_found":
+        return `Found ${event.total} songs`;
+      case "track_downloaded":
+        return `Downloaded "${event.track}"`;
+      case "track_skipped":
+        return `Skipping ${event.track} (${event.reason})`;
+      case "track_failed":
+        return event.reason ?? null;
+      case "output":
+        return event.line ?? null;
+      default:
+        // Progress percentages would flood the log
+        return null;
+    }
+  }
 
   function setupEventListener() {
-    EventsOn("update_in_download", (message: string) => {
-      // Create a new array reference to ensure reactivity
-      downloadMessages.value = [...downloadMessages.value, message];
-
-      // Update downloading status
-      if (message === "Done") {
-        isDownloading.value = false;
-      } else if (!isDownloading.value && message.includes("Downloading")) {
-        isDownloading.value = true;
+    // Every view calls this, the events must be counted once
+    if (listening) return;
+    listening = true;
+
+    EventsOn("download_event", (event: DownloadEvent) => {
+      const lines: string[] = [];
+      const line = message(event);
+      if (line) lines.push(line);
+
+      if (event.type === "job_started") {
+        runningJobs.add(event.jobId);
+      } else if (event.type === "job_finished") {
+        runningJobs.delete(event.jobId);
+        if (event.status === "failed") {
+          lines.push(`fatal_error: ${event.reason}`);
+        } else if (event.status === "cancelled") {
+          lines.push("cancelled");
+        }
+        lines.push("Done");
       }
+      isDownloading.value = runningJobs.size > 0;
 
-      console.log("Download update:", message);
+      if (lines.length > 0) {
+        // Create a new array reference to ensure reactivity
+        downloadMessages.value = [...downloadMessages.value, ...lines];
+      }
     });
   }
 
   function clearMessages() {
     downloadMessages.value = [];
-    isDownloading.value = false;
   }
 
   return { downloadMessages, isDownloading, setupEventListener, clearMessages };
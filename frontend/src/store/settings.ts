@@ -26,7 +26,8 @@ export const useSettingsStore = defineStore('settings', () => {
   async function loadSpotifyCredentials() {
     try {
       spotifyClientId.value = await GetSetting("spotify_client_id") || "";
-      spotifyClientSecret.value = await GetSetting("spotify_client_secret") || "";
+      // The secret is stored encrypted and never sent back, leave it empty to keep it
+      spotifyClientSecret.value = "";
       await checkCredentialsValidity();
     } catch (error) {
       console.error("Error loading Spotify credentials:", error);
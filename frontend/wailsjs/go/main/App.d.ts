@@ -1,6 +1,8 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {api} from '../models';
 import {database} from '../models';
+import {main} from '../models';
 
 export function AddArtist(arg1:string):Promise<boolean>;
 
@@ -8,25 +10,73 @@ export function CheckForUpdates():Promise<Record<string, any>>;
 
 export function ChooseDirectory():Promise<string>;
 
+export function ChooseExecutable():Promise<string>;
+
+export function ChooseExportFile():Promise<string>;
+
+export function ChooseImportFile():Promise<string>;
+
+export function ClearCache():Promise<void>;
+
+export function ClearDownloads():Promise<void>;
+
 export function Close():Promise<void>;
 
-export function GetAlbum(arg1:string):Promise<Record<string, any>>;
+export function DeleteDownload(arg1:number):Promise<void>;
+
+export function DetectMarket():Promise<string>;
 
-export function GetArtist(arg1:string):Promise<Record<string, any>>;
+export function ExportData(arg1:string,arg2:boolean):Promise<void>;
+
+export function GetAlbum(arg1:string):Promise<api.AlbumDetails>;
+
+export function GetArtist(arg1:string):Promise<api.ArtistDetails>;
 
 export function GetArtistsFromDB():Promise<Array<database.Artist>>;
 
+export function GetCacheStats():Promise<main.CacheStats>;
+
+export function GetDefaultReleaseGroups():Promise<Array<string>>;
+
+export function GetDownloadWorkers():Promise<number>;
+
+export function GetDownloads(arg1:database.DownloadFilter):Promise<Array<database.Download>>;
+
+export function GetLatestReleases(arg1:string):Promise<api.ArtistDetails>;
+
+export function GetMarket():Promise<string>;
+
+export function GetPlaylist(arg1:string):Promise<api.PlaylistDetails>;
+
 export function GetSetting(arg1:string):Promise<string>;
 
-export function GetTrack(arg1:string):Promise<Record<string, any>>;
+export function GetTrack(arg1:string):Promise<api.TrackDetails>;
 
 export function HasValidSpotifyCredentials():Promise<boolean>;
 
-export function IsANewRelease(arg1:string,arg2:Record<string, any>):Promise<boolean>;
+export function ImportData(arg1:string,arg2:string):Promise<database.ImportReport>;
+
+export function IsANewRelease(arg1:string,arg2:api.SimplifiedAlbum):Promise<boolean>;
+
+export function MarkReleaseSeen(arg1:string,arg2:api.SimplifiedAlbum):Promise<void>;
 
 export function RemoveArtist(arg1:string):Promise<boolean>;
 
-export function Search(arg1:string):Promise<Record<string, any>>;
+export function RerunDownload(arg1:number):Promise<boolean>;
+
+export function ResolveLink(arg1:string):Promise<api.Link>;
+
+export function Search(arg1:string,arg2:api.SearchOptions):Promise<api.SearchResult>;
+
+export function SetArtistReleaseGroups(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function SetDefaultReleaseGroups(arg1:Array<string>):Promise<void>;
+
+export function SetDownloadWorkers(arg1:number):Promise<void>;
+
+export function SetExecutablePaths(arg1:string,arg2:string):Promise<void>;
+
+export function SetMarket(arg1:string):Promise<void>;
 
 export function SetSetting(arg1:string,arg2:string):Promise<void>;
 
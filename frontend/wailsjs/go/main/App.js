@@ -14,10 +14,42 @@ export function ChooseDirectory() {
   return window['go']['main']['App']['ChooseDirectory']();
 }
 
+export function ChooseExecutable() {
+  return window['go']['main']['App']['ChooseExecutable']();
+}
+
+export function ChooseExportFile() {
+  return window['go']['main']['App']['ChooseExportFile']();
+}
+
+export function ChooseImportFile() {
+  return window['go']['main']['App']['ChooseImportFile']();
+}
+
+export function ClearCache() {
+  return window['go']['main']['App']['ClearCache']();
+}
+
+export function ClearDownloads() {
+  return window['go']['main']['App']['ClearDownloads']();
+}
+
 export function Close() {
   return window['go']['main']['App']['Close']();
 }
 
+export function DeleteDownload(arg1) {
+  return window['go']['main']['App']['DeleteDownload'](arg1);
+}
+
+export function DetectMarket() {
+  return window['go']['main']['App']['DetectMarket']();
+}
+
+export function ExportData(arg1, arg2) {
+  return window['go']['main']['App']['ExportData'](arg1, arg2);
+}
+
 export function GetAlbum(arg1) {
   return window['go']['main']['App']['GetAlbum'](arg1);
 }
@@ -30,6 +62,34 @@ export function GetArtistsFromDB() {
   return window['go']['main']['App']['GetArtistsFromDB']();
 }
 
+export function GetCacheStats() {
+  return window['go']['main']['App']['GetCacheStats']();
+}
+
+export function GetDefaultReleaseGroups() {
+  return window['go']['main']['App']['GetDefaultReleaseGroups']();
+}
+
+export function GetDownloadWorkers() {
+  return window['go']['main']['App']['GetDownloadWorkers']();
+}
+
+export function GetDownloads(arg1) {
+  return window['go']['main']['App']['GetDownloads'](arg1);
+}
+
+export function GetLatestReleases(arg1) {
+  return window['go']['main']['App']['GetLatestReleases'](arg1);
+}
+
+export function GetMarket() {
+  return window['go']['main']['App']['GetMarket']();
+}
+
+export function GetPlaylist(arg1) {
+  return window['go']['main']['App']['GetPlaylist'](arg1);
+}
+
 export function GetSetting(arg1) {
   return window['go']['main']['App']['GetSetting'](arg1);
 }
@@ -42,16 +102,52 @@ export function HasValidSpotifyCredentials() {
   return window['go']['main']['App']['HasValidSpotifyCredentials']();
 }
 
+export function ImportData(arg1, arg2) {
+  return window['go']['main']['App']['ImportData'](arg1, arg2);
+}
+
 export function IsANewRelease(arg1, arg2) {
   return window['go']['main']['App']['IsANewRelease'](arg1, arg2);
 }
 
+export function MarkReleaseSeen(arg1, arg2) {
+  return window['go']['main']['App']['MarkReleaseSeen'](arg1, arg2);
+}
+
 export function RemoveArtist(arg1) {
   return window['go']['main']['App']['RemoveArtist'](arg1);
 }
 
-export function Search(arg1) {
-  return window['go']['main']['App']['Search'](arg1);
+export function RerunDownload(arg1) {
+  return window['go']['main']['App']['RerunDownload'](arg1);
+}
+
+export function ResolveLink(arg1) {
+  return window['go']['main']['App']['ResolveLink'](arg1);
+}
+
+export function Search(arg1, arg2) {
+  return window['go']['main']['App']['Search'](arg1, arg2);
+}
+
+export function SetArtistReleaseGroups(arg1, arg2) {
+  return window['go']['main']['App']['SetArtistReleaseGroups'](arg1, arg2);
+}
+
+export function SetDefaultReleaseGroups(arg1) {
+  return window['go']['main']['App']['SetDefaultReleaseGroups'](arg1);
+}
+
+export function SetDownloadWorkers(arg1) {
+  return window['go']['main']['App']['SetDownloadWorkers'](arg1);
+}
+
+export function SetExecutablePaths(arg1, arg2) {
+  return window['go']['main']['App']['SetExecutablePaths'](arg1, arg2);
+}
+
+export function SetMarket(arg1) {
+  return window['go']['main']['App']['SetMarket'](arg1);
 }
 
 export function SetSetting(arg1, arg2) {
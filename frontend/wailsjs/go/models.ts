@@ -1,3 +1,984 @@
+export namespace api {
+	
+	export class Restrictions {
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Restrictions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.reason = source["reason"];
+	    }
+	}
+	export class SimplifiedTrack {
+	    id: string;
+	    name: string;
+	    type: string;
+	    artists: SimplifiedArtist[];
+	    disc_number: number;
+	    track_number: number;
+	    duration_ms: number;
+	    explicit: boolean;
+	    preview_url: string;
+	    is_local: boolean;
+	    is_playable?: boolean;
+	    restrictions?: Restrictions;
+	    uri: string;
+	    href: string;
+	    external_urls: ExternalURLs;
+	
+	    static createFrom(source: any = {}) {
+	        return new SimplifiedTrack(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.artists = this.convertValues(source["artists"], SimplifiedArtist);
+	        this.disc_number = source["disc_number"];
+	        this.track_number = source["track_number"];
+	        this.duration_ms = source["duration_ms"];
+	        this.explicit = source["explicit"];
+	        this.preview_url = source["preview_url"];
+	        this.is_local = source["is_local"];
+	        this.is_playable = source["is_playable"];
+	        this.restrictions = this.convertValues(source["restrictions"], Restrictions);
+	        this.uri = source["uri"];
+	        this.href = source["href"];
+	        this.external_urls = this.convertValues(source["external_urls"], ExternalURLs);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Paging_spotwrap_next_api_SimplifiedTrack_ {
+	    href: string;
+	    items: SimplifiedTrack[];
+	    limit: number;
+	    next: string;
+	    offset: number;
+	    previous: string;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Paging_spotwrap_next_api_SimplifiedTrack_(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.href = source["href"];
+	        this.items = this.convertValues(source["items"], SimplifiedTrack);
+	        this.limit = source["limit"];
+	        this.next = source["next"];
+	        this.offset = source["offset"];
+	        this.previous = source["previous"];
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Copyright {
+	    text: string;
+	    type: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Copyright(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.type = source["type"];
+	    }
+	}
+	export class ExternalURLs {
+	    spotify: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExternalURLs(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.spotify = source["spotify"];
+	    }
+	}
+	export class SimplifiedArtist {
+	    id: string;
+	    name: string;
+	    type: string;
+	    uri: string;
+	    href: string;
+	    external_urls: ExternalURLs;
+	
+	    static createFrom(source: any = {}) {
+	        return new SimplifiedArtist(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.uri = source["uri"];
+	        this.href = source["href"];
+	        this.external_urls = this.convertValues(source["external_urls"], ExternalURLs);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Image {
+	    url: string;
+	    height: number;
+	    width: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Image(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.height = source["height"];
+	        this.width = source["width"];
+	    }
+	}
+	export class Album {
+	    id: string;
+	    name: string;
+	    type: string;
+	    album_type: string;
+	    total_tracks: number;
+	    release_date: string;
+	    release_date_precision: string;
+	    images: Image[];
+	    artists: SimplifiedArtist[];
+	    available_markets?: string[];
+	    uri: string;
+	    href: string;
+	    external_urls: ExternalURLs;
+	    genres: string[];
+	    label: string;
+	    popularity: number;
+	    copyrights: Copyright[];
+	    tracks: Paging_spotwrap_next_api_SimplifiedTrack_;
+	
+	    static createFrom(source: any = {}) {
+	        return new Album(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.album_type = source["album_type"];
+	        this.total_tracks = source["total_tracks"];
+	        this.release_date = source["release_date"];
+	        this.release_date_precision = source["release_date_precision"];
+	        this.images = this.convertValues(source["images"], Image);
+	        this.artists = this.convertValues(source["artists"], SimplifiedArtist);
+	        this.available_markets = source["available_markets"];
+	        this.uri = source["uri"];
+	        this.href = source["href"];
+	        this.external_urls = this.convertValues(source["external_urls"], ExternalURLs);
+	        this.genres = source["genres"];
+	        this.label = source["label"];
+	        this.popularity = source["popularity"];
+	        this.copyrights = this.convertValues(source["copyrights"], Copyright);
+	        this.tracks = this.convertValues(source["tracks"], Paging_spotwrap_next_api_SimplifiedTrack_);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlbumDetails {
+	    album: Album;
+	    tracks: SimplifiedTrack[];
+	    market?: string;
+	    unavailable_tracks: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlbumDetails(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.album = this.convertValues(source["album"], Album);
+	        this.tracks = this.convertValues(source["tracks"], SimplifiedTrack);
+	        this.market = source["market"];
+	        this.unavailable_tracks = source["unavailable_tracks"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Followers {
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Followers(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	    }
+	}
+	export class Artist {
+	    id: string;
+	    name: string;
+	    type: string;
+	    uri: string;
+	    href: string;
+	    external_urls: ExternalURLs;
+	    genres: string[];
+	    images: Image[];
+	    popularity: number;
+	    followers: Followers;
+	
+	    static createFrom(source: any = {}) {
+	        return new Artist(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.uri = source["uri"];
+	        this.href = source["href"];
+	        this.external_urls = this.convertValues(source["external_urls"], ExternalURLs);
+	        this.genres = source["genres"];
+	        this.images = this.convertValues(source["images"], Image);
+	        this.popularity = source["popularity"];
+	        this.followers = this.convertValues(source["followers"], Followers);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SimplifiedAlbum {
+	    id: string;
+	    name: string;
+	    type: string;
+	    album_type: string;
+	    album_group?: string;
+	    total_tracks: number;
+	    release_date: string;
+	    release_date_precision: string;
+	    images: Image[];
+	    artists: SimplifiedArtist[];
+	    available_markets?: string[];
+	    uri: string;
+	    href: string;
+	    external_urls: ExternalURLs;
+	
+	    static createFrom(source: any = {}) {
+	        return new SimplifiedAlbum(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.album_type = source["album_type"];
+	        this.album_group = source["album_group"];
+	        this.total_tracks = source["total_tracks"];
+	        this.release_date = source["release_date"];
+	        this.release_date_precision = source["release_date_precision"];
+	        this.images = this.convertValues(source["images"], Image);
+	        this.artists = this.convertValues(source["artists"], SimplifiedArtist);
+	        this.available_markets = source["available_markets"];
+	        this.uri = source["uri"];
+	        this.href = source["href"];
+	        this.external_urls = this.convertValues(source["external_urls"], ExternalURLs);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Track {
+	    id: string;
+	    name: string;
+	    type: string;
+	    album: SimplifiedAlbum;
+	    artists: SimplifiedArtist[];
+	    disc_number: number;
+	    track_number: number;
+	    duration_ms: number;
+	    explicit: boolean;
+	    preview_url: string;
+	    popularity: number;
+	    is_local: boolean;
+	    is_playable?: boolean;
+	    restrictions?: Restrictions;
+	    uri: string;
+	    href: string;
+	    external_urls: ExternalURLs;
+	
+	    static createFrom(source: any = {}) {
+	        return new Track(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.album = this.convertValues(source["album"], SimplifiedAlbum);
+	        this.artists = this.convertValues(source["artists"], SimplifiedArtist);
+	        this.disc_number = source["disc_number"];
+	        this.track_number = source["track_number"];
+	        this.duration_ms = source["duration_ms"];
+	        this.explicit = source["explicit"];
+	        this.preview_url = source["preview_url"];
+	        this.popularity = source["popularity"];
+	        this.is_local = source["is_local"];
+	        this.is_playable = source["is_playable"];
+	        this.restrictions = this.convertValues(source["restrictions"], Restrictions);
+	        this.uri = source["uri"];
+	        this.href = source["href"];
+	        this.external_urls = this.convertValues(source["external_urls"], ExternalURLs);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ArtistDetails {
+	    artist: Artist;
+	    top_tracks?: Track[];
+	    albums: SimplifiedAlbum[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ArtistDetails(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.artist = this.convertValues(source["artist"], Artist);
+	        this.top_tracks = this.convertValues(source["top_tracks"], Track);
+	        this.albums = this.convertValues(source["albums"], SimplifiedAlbum);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CacheCounters {
+	    hits: number;
+	    revalidated: number;
+	    misses: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheCounters(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hits = source["hits"];
+	        this.revalidated = source["revalidated"];
+	        this.misses = source["misses"];
+	    }
+	}
+	
+	
+	
+	
+	export class Link {
+	    kind: string;
+	    id: string;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Link(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.id = source["id"];
+	        this.url = source["url"];
+	    }
+	}
+	export class Paging_spotwrap_next_api_Artist_ {
+	    href: string;
+	    items: Artist[];
+	    limit: number;
+	    next: string;
+	    offset: number;
+	    previous: string;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Paging_spotwrap_next_api_Artist_(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.href = source["href"];
+	        this.items = this.convertValues(source["items"], Artist);
+	        this.limit = source["limit"];
+	        this.next = source["next"];
+	        this.offset = source["offset"];
+	        this.previous = source["previous"];
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PlaylistTrack {
+	    added_at: string;
+	    is_local: boolean;
+	    track?: Track;
+	    unavailable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaylistTrack(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added_at = source["added_at"];
+	        this.is_local = source["is_local"];
+	        this.track = this.convertValues(source["track"], Track);
+	        this.unavailable = source["unavailable"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Paging_spotwrap_next_api_PlaylistTrack_ {
+	    href: string;
+	    items: PlaylistTrack[];
+	    limit: number;
+	    next: string;
+	    offset: number;
+	    previous: string;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Paging_spotwrap_next_api_PlaylistTrack_(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.href = source["href"];
+	        this.items = this.convertValues(source["items"], PlaylistTrack);
+	        this.limit = source["limit"];
+	        this.next = source["next"];
+	        this.offset = source["offset"];
+	        this.previous = source["previous"];
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Paging_spotwrap_next_api_SimplifiedAlbum_ {
+	    href: string;
+	    items: SimplifiedAlbum[];
+	    limit: number;
+	    next: string;
+	    offset: number;
+	    previous: string;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Paging_spotwrap_next_api_SimplifiedAlbum_(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.href = source["href"];
+	        this.items = this.convertValues(source["items"], SimplifiedAlbum);
+	        this.limit = source["limit"];
+	        this.next = source["next"];
+	        this.offset = source["offset"];
+	        this.previous = source["previous"];
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class Paging_spotwrap_next_api_Track_ {
+	    href: string;
+	    items: Track[];
+	    limit: number;
+	    next: string;
+	    offset: number;
+	    previous: string;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Paging_spotwrap_next_api_Track_(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.href = source["href"];
+	        this.items = this.convertValues(source["items"], Track);
+	        this.limit = source["limit"];
+	        this.next = source["next"];
+	        this.offset = source["offset"];
+	        this.previous = source["previous"];
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PlaylistOwner {
+	    id: string;
+	    display_name: string;
+	    type: string;
+	    uri: string;
+	    href: string;
+	    external_urls: ExternalURLs;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaylistOwner(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.display_name = source["display_name"];
+	        this.type = source["type"];
+	        this.uri = source["uri"];
+	        this.href = source["href"];
+	        this.external_urls = this.convertValues(source["external_urls"], ExternalURLs);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Playlist {
+	    id: string;
+	    name: string;
+	    type: string;
+	    description: string;
+	    public: boolean;
+	    collaborative: boolean;
+	    snapshot_id: string;
+	    owner: PlaylistOwner;
+	    images: Image[];
+	    followers: Followers;
+	    uri: string;
+	    href: string;
+	    external_urls: ExternalURLs;
+	    tracks: Paging_spotwrap_next_api_PlaylistTrack_;
+	
+	    static createFrom(source: any = {}) {
+	        return new Playlist(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.description = source["description"];
+	        this.public = source["public"];
+	        this.collaborative = source["collaborative"];
+	        this.snapshot_id = source["snapshot_id"];
+	        this.owner = this.convertValues(source["owner"], PlaylistOwner);
+	        this.images = this.convertValues(source["images"], Image);
+	        this.followers = this.convertValues(source["followers"], Followers);
+	        this.uri = source["uri"];
+	        this.href = source["href"];
+	        this.external_urls = this.convertValues(source["external_urls"], ExternalURLs);
+	        this.tracks = this.convertValues(source["tracks"], Paging_spotwrap_next_api_PlaylistTrack_);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PlaylistDetails {
+	    playlist: Playlist;
+	    tracks: PlaylistTrack[];
+	    market?: string;
+	    local_count: number;
+	    unavailable_count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaylistDetails(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.playlist = this.convertValues(source["playlist"], Playlist);
+	        this.tracks = this.convertValues(source["tracks"], PlaylistTrack);
+	        this.market = source["market"];
+	        this.local_count = source["local_count"];
+	        this.unavailable_count = source["unavailable_count"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+	export class SearchOptions {
+	    types: string[];
+	    market: string;
+	    limit: number;
+	    offset: number;
+	    include_external: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.types = source["types"];
+	        this.market = source["market"];
+	        this.limit = source["limit"];
+	        this.offset = source["offset"];
+	        this.include_external = source["include_external"];
+	    }
+	}
+	export class SearchResult {
+	    artists?: Paging_spotwrap_next_api_Artist_;
+	    albums?: Paging_spotwrap_next_api_SimplifiedAlbum_;
+	    tracks?: Paging_spotwrap_next_api_Track_;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.artists = this.convertValues(source["artists"], Paging_spotwrap_next_api_Artist_);
+	        this.albums = this.convertValues(source["albums"], Paging_spotwrap_next_api_SimplifiedAlbum_);
+	        this.tracks = this.convertValues(source["tracks"], Paging_spotwrap_next_api_Track_);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+	
+	export class TrackDetails {
+	    track: Track;
+	    market?: string;
+	    available: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrackDetails(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.track = this.convertValues(source["track"], Track);
+	        this.market = source["market"];
+	        this.available = source["available"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace database {
 	
 	export class Artist {
@@ -6,6 +987,15 @@ export namespace database {
 	    LastChecked: any;
 	    // Go type: time
 	    CreatedAt: any;
+	    ReleaseGroups: string[];
+	    // Go type: time
+	    SyncedAt: any;
+	    Name: string;
+	    ImageURL: string;
+	    Genres: string[];
+	    Followers: number;
+	    // Go type: time
+	    MetadataUpdatedAt: any;
 	
 	    static createFrom(source: any = {}) {
 	        return new Artist(source);
@@ -16,6 +1006,334 @@ export namespace database {
 	        this.SpotifyID = source["SpotifyID"];
 	        this.LastChecked = this.convertValues(source["LastChecked"], null);
 	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	        this.ReleaseGroups = source["ReleaseGroups"];
+	        this.SyncedAt = this.convertValues(source["SyncedAt"], null);
+	        this.Name = source["Name"];
+	        this.ImageURL = source["ImageURL"];
+	        this.Genres = source["Genres"];
+	        this.Followers = source["Followers"];
+	        this.MetadataUpdatedAt = this.convertValues(source["MetadataUpdatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CacheStats {
+	    entries: number;
+	    sizeBytes: number;
+	    expired: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = source["entries"];
+	        this.sizeBytes = source["sizeBytes"];
+	        this.expired = source["expired"];
+	    }
+	}
+	export class Download {
+	    ID: number;
+	    Link: string;
+	    Kind: string;
+	    SpotifyID: string;
+	    OutputPath: string;
+	    Format: string;
+	    Bitrate: string;
+	    // Go type: time
+	    StartedAt: any;
+	    // Go type: time
+	    FinishedAt: any;
+	    Status: string;
+	    Error: string;
+	    Files: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Download(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Link = source["Link"];
+	        this.Kind = source["Kind"];
+	        this.SpotifyID = source["SpotifyID"];
+	        this.OutputPath = source["OutputPath"];
+	        this.Format = source["Format"];
+	        this.Bitrate = source["Bitrate"];
+	        this.StartedAt = this.convertValues(source["StartedAt"], null);
+	        this.FinishedAt = this.convertValues(source["FinishedAt"], null);
+	        this.Status = source["Status"];
+	        this.Error = source["Error"];
+	        this.Files = source["Files"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DownloadFilter {
+	    Status: string;
+	    Kind: string;
+	    Search: string;
+	    // Go type: time
+	    Since: any;
+	    // Go type: time
+	    Until: any;
+	    Limit: number;
+	    Offset: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DownloadFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Status = source["Status"];
+	        this.Kind = source["Kind"];
+	        this.Search = source["Search"];
+	        this.Since = this.convertValues(source["Since"], null);
+	        this.Until = this.convertValues(source["Until"], null);
+	        this.Limit = source["Limit"];
+	        this.Offset = source["Offset"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImportConflict {
+	    kind: string;
+	    key: string;
+	    existing: string;
+	    imported: string;
+	    resolved: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.key = source["key"];
+	        this.existing = source["existing"];
+	        this.imported = source["imported"];
+	        this.resolved = source["resolved"];
+	    }
+	}
+	export class ImportReport {
+	    artistsAdded: number;
+	    artistsUpdated: number;
+	    artistsRemoved: number;
+	    settingsChanged: number;
+	    conflicts: ImportConflict[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.artistsAdded = source["artistsAdded"];
+	        this.artistsUpdated = source["artistsUpdated"];
+	        this.artistsRemoved = source["artistsRemoved"];
+	        this.settingsChanged = source["settingsChanged"];
+	        this.conflicts = this.convertValues(source["conflicts"], ImportConflict);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace main {
+	
+	export class CacheStats {
+	    stored: database.CacheStats;
+	    requests: api.CacheCounters;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.stored = this.convertValues(source["stored"], database.CacheStats);
+	        this.requests = this.convertValues(source["requests"], api.CacheCounters);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace spotdl {
+	
+	export class Executable {
+	    path: string;
+	    source: string;
+	    version: string;
+	    works: boolean;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Executable(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.source = source["source"];
+	        this.version = source["version"];
+	        this.works = source["works"];
+	        this.error = source["error"];
+	    }
+	}
+	export class Diagnostics {
+	    spotdl?: Executable;
+	    ffmpeg?: Executable;
+	    candidates: Executable[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Diagnostics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.spotdl = this.convertValues(source["spotdl"], Executable);
+	        this.ffmpeg = this.convertValues(source["ffmpeg"], Executable);
+	        this.candidates = this.convertValues(source["candidates"], Executable);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class Job {
+	    id: number;
+	    link: string;
+	    outputPath: string;
+	    format: string;
+	    bitrate: string;
+	    priority: number;
+	    status: string;
+	    error: string;
+	    attempts: number;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.link = source["link"];
+	        this.outputPath = source["outputPath"];
+	        this.format = source["format"];
+	        this.bitrate = source["bitrate"];
+	        this.priority = source["priority"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.attempts = source["attempts"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -1,7 +1,28 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {spotdl} from '../models';
 import {context} from '../models';
 
+export function Cancel(arg1:number):Promise<void>;
+
+export function CancelDownload(arg1:number):Promise<void>;
+
+export function ClearFinishedJobs():Promise<number>;
+
+export function Diagnostics():Promise<spotdl.Diagnostics>;
+
 export function Download(arg1:string,arg2:string,arg3:string,arg4:string,arg5:Array<string>):Promise<boolean>;
 
+export function Enqueue(arg1:string,arg2:string,arg3:string,arg4:string,arg5:number):Promise<number>;
+
+export function ListJobs():Promise<Array<spotdl.Job>>;
+
+export function Pause(arg1:number):Promise<void>;
+
+export function RemoveJob(arg1:number):Promise<void>;
+
+export function Resume(arg1:number):Promise<void>;
+
+export function Retry(arg1:number):Promise<void>;
+
 export function Startup(arg1:context.Context):Promise<void>;
@@ -2,10 +2,50 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function Cancel(arg1) {
+  return window['go']['spotdl']['Downloader']['Cancel'](arg1);
+}
+
+export function CancelDownload(arg1) {
+  return window['go']['spotdl']['Downloader']['CancelDownload'](arg1);
+}
+
+export function ClearFinishedJobs() {
+  return window['go']['spotdl']['Downloader']['ClearFinishedJobs']();
+}
+
+export function Diagnostics() {
+  return window['go']['spotdl']['Downloader']['Diagnostics']();
+}
+
 export function Download(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['spotdl']['Downloader']['Download'](arg1, arg2, arg3, arg4, arg5);
 }
 
+export function Enqueue(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['spotdl']['Downloader']['Enqueue'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function ListJobs() {
+  return window['go']['spotdl']['Downloader']['ListJobs']();
+}
+
+export function Pause(arg1) {
+  return window['go']['spotdl']['Downloader']['Pause'](arg1);
+}
+
+export function RemoveJob(arg1) {
+  return window['go']['spotdl']['Downloader']['RemoveJob'](arg1);
+}
+
+export function Resume(arg1) {
+  return window['go']['spotdl']['Downloader']['Resume'](arg1);
+}
+
+export function Retry(arg1) {
+  return window['go']['spotdl']['Downloader']['Retry'](arg1);
+}
+
 export function Startup(arg1) {
   return window['go']['spotdl']['Downloader']['Startup'](arg1);
 }
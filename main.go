@@ -8,8 +8,9 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"spotwrap-next/api"
 	"spotwrap-next/autostart"
-	"spotwrap-next/spotdl"
+	"spotwrap-next/database"
 	"spotwrap-next/utils"
 	"syscall"
 
@@ -23,8 +24,19 @@ var assets embed.FS
 
 func main() {
 	noGUI := flag.Bool("no-gui", false, "Run in background mode")
+	exportPath := flag.String("export", "", "Export subscriptions and settings to a JSON file and exit")
+	importPath := flag.String("import", "", "Import subscriptions and settings from a JSON file and exit")
+	importMode := flag.String("import-mode", database.ImportMerge, "How -import applies the file: merge or replace")
+	includeSecrets := flag.Bool("include-secrets", false, "Include the Spotify client secret in -export")
 	flag.Parse()
 
+	if *exportPath != "" || *importPath != "" {
+		if err := runDataTransfer(*exportPath, *importPath, *importMode, *includeSecrets); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
 	if *noGUI {
 		runInBackground()
 		return
@@ -43,7 +55,7 @@ func startGUI() error {
 	}
 
 	utils := utils.New()
-	downloader := spotdl.NewDownloader()
+	downloader := app.newDownloader()
 	autostartSvc := autostart.New("spotwrap-next", "Spotwrap Next")
 
 	// Create application with options
@@ -58,6 +70,7 @@ func startGUI() error {
 		OnStartup: func(ctx context.Context) {
 			app.startup(ctx)
 			downloader.Startup(ctx)
+			go app.seedReleases(app.ctx)
 		},
 		Bind: []any{
 			app,
@@ -65,10 +78,12 @@ func startGUI() error {
 			downloader,
 			autostartSvc,
 		},
+		ErrorFormatter:           formatError,
 		CSSDragProperty:          "windows",
 		CSSDragValue:             "1",
 		EnableDefaultContextMenu: false,
 		OnShutdown: func(ctx context.Context) {
+			app.downloads.Shutdown() // stop spotdl before closing the database it records to
 			app.Close()
 			utils.CleanUp() // clean the cover directory
 		},
@@ -81,6 +96,52 @@ func startGUI() error {
 	return nil
 }
 
+// formatError turns Spotify API errors into a payload with a stable code the
+// frontend can act on, and leaves every other error as its message
+func formatError(err error) any {
+	if code := api.ErrorCode(err); code != api.ErrCodeUnknown {
+		return api.NewErrorPayload(err)
+	}
+	return err.Error()
+}
+
+// runDataTransfer runs the -export and -import flags without starting the GUI
+func runDataTransfer(exportPath, importPath, importMode string, includeSecrets bool) error {
+	app, err := NewApp()
+	if err != nil {
+		return fmt.Errorf("failed to initialize app: %w", err)
+	}
+	// Skip startup, there is no need to fetch a Spotify token
+	app.ctx, app.cancel = context.WithCancel(context.Background())
+	defer app.Close()
+
+	if exportPath != "" {
+		if err := app.ExportData(exportPath, includeSecrets); err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		fmt.Printf("Exported to %s\n", exportPath)
+	}
+
+	if importPath != "" {
+		report, metadataDone, err := app.importData(importPath, importMode)
+		if err != nil {
+			return fmt.Errorf("import failed: %w", err)
+		}
+		fmt.Printf("Imported %s: %d artists added, %d updated, %d removed, %d settings changed\n",
+			importPath, report.ArtistsAdded, report.ArtistsUpdated, report.ArtistsRemoved, report.SettingsChanged)
+		for _, c := range report.Conflicts {
+			if c.Kind == "secret" {
+				fmt.Printf("Conflict on %s %s: %s\n", c.Kind, c.Key, c.Resolved)
+				continue
+			}
+			fmt.Printf("Conflict on %s %s: existing %q, imported %q, %s\n", c.Kind, c.Key, c.Existing, c.Imported, c.Resolved)
+		}
+		// Closing the app would cancel the fetch and close the database under it
+		<-metadataDone
+	}
+	return nil
+}
+
 func runInBackground() {
 	log.Println("Running in background mode")
 
@@ -90,7 +151,7 @@ func runInBackground() {
 	}
 
 	app.startup(context.Background())
-	app.checkForNewReleases()
+	app.checkForNewReleases(app.ctx)
 	app.startBackgroundChecker()
 
 	// Set up signal handling for graceful shutdown
@@ -101,4 +162,5 @@ func runInBackground() {
 
 	log.Println("Shutting down background service")
 	app.stopBackgroundChecker()
+	app.Close()
 }
@@ -0,0 +1,183 @@
+package spotdl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// binaries extracts the embedded executables once into a cache directory
+// named after their hash, instead of into a new temporary directory for
+// every download. A new app version with other binaries uses a new directory.
+type binaries struct {
+	mu       sync.Mutex // serializes extraction between downloads
+	dir      string     // directory of the verified executables, empty until extracted
+	checksum []string   // SHA-256 of each bundled file, in bundledFiles order
+}
+
+// extractedBinaries is shared by every Downloader of the process
+var extractedBinaries binaries
+
+// bundledDir returns the directory holding the embedded executables,
+// extracting and verifying them on first use
+func bundledDir() (string, error) {
+	b := &extractedBinaries
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.dir != "" {
+		// Extracted earlier by this process, make sure nothing deleted them since
+		if b.present() {
+			return b.dir, nil
+		}
+		b.dir = ""
+	}
+	if len(bundledFiles) == 0 {
+		return "", errors.New("no spotdl binary is bundled for this platform")
+	}
+
+	if b.checksum == nil {
+		for _, f := range bundledFiles {
+			sum, err := embeddedChecksum(f)
+			if err != nil {
+				return "", err
+			}
+			b.checksum = append(b.checksum, sum)
+		}
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	root := filepath.Join(cacheDir, "spotwrap-next", "bin")
+	dir := filepath.Join(root, versionOf(b.checksum))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create binary cache: %w", err)
+	}
+
+	for i, f := range bundledFiles {
+		target := filepath.Join(dir, f.name)
+		if sum, err := fileChecksum(target); err == nil && sum == b.checksum[i] {
+			continue
+		}
+		log.Printf("Extracting %s to %s", f.name, dir)
+		if err := extractAtomically(f, target, b.checksum[i]); err != nil {
+			return "", err
+		}
+	}
+
+	b.dir = dir
+	removeStaleVersions(root, dir)
+	return dir, nil
+}
+
+// present reports whether the extracted executables still exist
+func (b *binaries) present() bool {
+	for _, f := range bundledFiles {
+		if _, err := os.Stat(filepath.Join(b.dir, f.name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// versionOf names the cache directory after the checksums of the bundled files
+func versionOf(checksums []string) string {
+	h := sha256.New()
+	for _, sum := range checksums {
+		io.WriteString(h, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// embeddedChecksum returns the SHA-256 of a bundled file
+func embeddedChecksum(f bundledFile) (string, error) {
+	data, err := f.fs.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read embedded binary %s: %w", f.path, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// fileChecksum returns the SHA-256 of the file at path
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// extractAtomically writes f next to target and renames it into place once
+// verified, so that another process never runs a half-written executable
+func extractAtomically(f bundledFile, target, checksum string) error {
+	data, err := f.fs.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read embedded binary %s: %w", f.path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), f.name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", f.name, err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to extract %s: %w", f.name, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to extract %s: %w", f.name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", f.name, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", f.name, err)
+	}
+
+	if sum, err := fileChecksum(tmp.Name()); err != nil || sum != checksum {
+		return fmt.Errorf("extracted %s is corrupted", f.name)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		// Another process may have put a verified copy in place meanwhile
+		if sum, sumErr := fileChecksum(target); sumErr == nil && sum == checksum {
+			return nil
+		}
+		return fmt.Errorf("failed to extract %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// removeStaleVersions deletes the binaries extracted by other app versions.
+// Failures are ignored: an older version may still be running them.
+func removeStaleVersions(root, current string) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if !entry.IsDir() || path == current {
+			continue
+		}
+		if err := os.RemoveAll(path); err == nil {
+			log.Printf("Removed old binaries %s", path)
+		}
+	}
+}
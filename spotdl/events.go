@@ -0,0 +1,184 @@
+package spotdl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Types of the events emitted as download_event
+const (
+	EventJobStarted       = "job_started"
+	EventTrackFound       = "track_found"       // Total songs found for the link
+	EventTrackDownloading = "track_downloading" // Percent of Track done
+	EventTrackDownloaded  = "track_downloaded"
+	EventTrackSkipped     = "track_skipped"
+	EventTrackFailed      = "track_failed"
+	EventJobFinished      = "job_finished" // Status and the counts of the run
+	EventOutput           = "output"       // a line that matched nothing else
+)
+
+// Event is a typed progress report of a download job
+type Event struct {
+	JobID      int64  `json:"jobId"`
+	Type       string `json:"type"`
+	Link       string `json:"link,omitempty"`
+	Track      string `json:"track,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Percent    int    `json:"percent,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Total      int    `json:"total,omitempty"`
+	Downloaded int    `json:"downloaded,omitempty"`
+	Skipped    int    `json:"skipped,omitempty"`
+	Failed     int    `json:"failed,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Line       string `json:"line,omitempty"`
+}
+
+// Lines printed by spotdl 4
+var (
+	// Found 12 songs in Album Name (Album)
+	foundPattern = regexp.MustCompile(`^Found (\d+) songs? in `)
+	// Downloaded "Artist - Title": https://music.youtube.com/watch?v=...
+	downloadedPattern = regexp.MustCompile(`^Downloaded "(.+)": (\S+)`)
+	// Skipping Artist - Title (file already exists) (duplicate)
+	skippedPattern = regexp.MustCompile(`^Skipping (.+?) \((.+?)\)`)
+	// LookupError: No results found for song: Artist - Title
+	// Only the errors spotdl reports for a single song and goes on after.
+	// Any other error is fatal and ends the run with a failed job.
+	failedPattern = regexp.MustCompile(`^(LookupError|AudioProviderError|DownloaderError|FFmpegError|ConverterError|MetadataError): (.+)$`)
+	songPattern   = regexp.MustCompile(`song: (.+)$`)
+	// Artist - Title   Downloading ━━━━━━━━━━   45%
+	percentPattern = regexp.MustCompile(`^(.+?)\s+(?:Downloading|Converting|Embedding metadata)\b.*?(\d{1,3})%`)
+)
+
+// progress turns the output of one download into events and keeps its counts
+type progress struct {
+	d     *Downloader
+	jobID int64
+
+	mu         sync.Mutex
+	total      int
+	downloaded map[string]bool
+	skipped    int
+	failed     int
+}
+
+func newProgress(d *Downloader, jobID int64) *progress {
+	return &progress{d: d, jobID: jobID, downloaded: make(map[string]bool)}
+}
+
+// emit sends e to the frontend as download_event
+func (p *progress) emit(e Event) {
+	e.JobID = p.jobID
+	if p.d.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(p.d.ctx, "download_event", e)
+}
+
+// line parses one line printed by spotdl
+func (p *progress) line(line string) {
+	p.mu.Lock()
+	e := p.parse(line)
+	p.mu.Unlock()
+	p.emit(e)
+}
+
+// parse returns the event for line and updates the counts. The caller holds p.mu.
+func (p *progress) parse(line string) Event {
+	if m := downloadedPattern.FindStringSubmatch(line); m != nil {
+		p.downloaded[m[1]] = true
+		return Event{Type: EventTrackDownloaded, Track: m[1], URL: m[2]}
+	}
+	if m := skippedPattern.FindStringSubmatch(line); m != nil {
+		p.skipped++
+		return Event{Type: EventTrackSkipped, Track: m[1], Reason: m[2]}
+	}
+	if m := failedPattern.FindStringSubmatch(line); m != nil {
+		p.failed++
+		e := Event{Type: EventTrackFailed, Reason: line}
+		if song := songPattern.FindStringSubmatch(m[2]); song != nil {
+			e.Track = song[1]
+		}
+		return e
+	}
+	if m := foundPattern.FindStringSubmatch(line); m != nil {
+		total, _ := strconv.Atoi(m[1])
+		p.total += total
+		return Event{Type: EventTrackFound, Total: total}
+	}
+	if m := percentPattern.FindStringSubmatch(line); m != nil {
+		percent, _ := strconv.Atoi(m[2])
+		return Event{Type: EventTrackDownloading, Track: strings.TrimSpace(m[1]), Percent: min(percent, 100)}
+	}
+	return Event{Type: EventOutput, Line: line}
+}
+
+// finish emits the job_finished event with the counts of the run
+func (p *progress) finish(status, reason string) {
+	p.mu.Lock()
+	e := Event{
+		Type:       EventJobFinished,
+		Status:     status,
+		Reason:     reason,
+		Total:      p.total,
+		Downloaded: len(p.downloaded),
+		Skipped:    p.skipped,
+		Failed:     p.failed,
+	}
+	p.mu.Unlock()
+	p.emit(e)
+}
+
+// completed returns the songs spotdl reported as fully downloaded
+func (p *progress) completed() map[string]bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make(map[string]bool, len(p.downloaded))
+	for name := range p.downloaded {
+		names[name] = true
+	}
+	return names
+}
+
+// scanLines splits on \n and on the \r used to redraw progress bars
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// pipeReader reads a pipe line by line and passes each line to p
+func (d *Downloader) pipeReader(wg *sync.WaitGroup, pipe io.ReadCloser, p *progress) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(pipe)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	scanner.Split(scanLines)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		log.Print(line)
+		p.line(line)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Error reading spotdl output: %v", err)
+		// Keep draining so that spotdl doesn't block on a full pipe
+		io.Copy(io.Discard, pipe)
+	}
+}
@@ -0,0 +1,243 @@
+package spotdl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Where an executable was found
+const (
+	SourceSetting = "setting" // path configured by the user
+	SourcePath    = "path"    // found on PATH
+	SourceBundled = "bundled" // embedded in the app
+)
+
+// probeTimeout bounds a version check. spotdl builds made with PyInstaller
+// unpack themselves before running, which takes a few seconds.
+const probeTimeout = 30 * time.Second
+
+// ffmpegVersionPattern extracts the version from the first line of ffmpeg -version
+var ffmpegVersionPattern = regexp.MustCompile(`version (\S+)`)
+
+// Executable describes a spotdl or ffmpeg executable
+type Executable struct {
+	Path    string `json:"path"`
+	Source  string `json:"source"`
+	Version string `json:"version"`
+	Works   bool   `json:"works"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Diagnostics reports the executables downloads use
+type Diagnostics struct {
+	Spotdl *Executable `json:"spotdl"` // nil when no spotdl works
+	FFmpeg *Executable `json:"ffmpeg"` // nil when spotdl looks for ffmpeg itself
+	// Candidates lists every executable considered, in order of preference
+	Candidates []Executable `json:"candidates"`
+}
+
+// executables holds the user's executable settings and the result of past version checks
+type executables struct {
+	mu         sync.Mutex
+	spotdlPath string
+	ffmpegPath string
+	probes     map[string]probeResult
+}
+
+// probeResult is a version check of the file at a path, valid while the file is unchanged
+type probeResult struct {
+	modTime time.Time
+	size    int64
+	version string
+	err     error
+}
+
+// setExecutablePaths sets the spotdl and ffmpeg executables to prefer over
+// the ones on PATH and the bundled ones. Empty paths are ignored.
+func (d *Downloader) setExecutablePaths(spotdlPath, ffmpegPath string) {
+	d.tools.mu.Lock()
+	defer d.tools.mu.Unlock()
+	d.tools.spotdlPath = spotdlPath
+	d.tools.ffmpegPath = ffmpegPath
+}
+
+// Diagnostics checks which spotdl and ffmpeg executables downloads use and whether they run
+func (d *Downloader) Diagnostics() Diagnostics {
+	var diag Diagnostics
+	ctx := d.baseContext()
+
+	spotdl, candidates := d.findSpotdl(ctx, true)
+	diag.Spotdl = spotdl
+	diag.Candidates = append(diag.Candidates, candidates...)
+
+	ffmpeg, candidates := d.findFFmpeg(ctx)
+	diag.FFmpeg = ffmpeg
+	diag.Candidates = append(diag.Candidates, candidates...)
+	return diag
+}
+
+// findSpotdl returns the first working spotdl among the configured one, the
+// one on PATH and the bundled one, along with every candidate checked. The
+// bundled spotdl is only run when probeBundled is set, downloads trust it.
+func (d *Downloader) findSpotdl(ctx context.Context, probeBundled bool) (*Executable, []Executable) {
+	d.tools.mu.Lock()
+	configured := d.tools.spotdlPath
+	d.tools.mu.Unlock()
+
+	var candidates []Executable
+	for _, c := range userCandidates(configured, "spotdl") {
+		exe := d.probe(ctx, c, "--version")
+		candidates = append(candidates, exe)
+		if exe.Works {
+			return &exe, candidates
+		}
+	}
+
+	exe := d.bundledSpotdl(ctx, probeBundled)
+	candidates = append(candidates, exe)
+	if exe.Works {
+		return &exe, candidates
+	}
+	return nil, candidates
+}
+
+// findFFmpeg returns the first working ffmpeg among the configured one, the
+// one on PATH and the bundled one, along with every candidate checked
+func (d *Downloader) findFFmpeg(ctx context.Context) (*Executable, []Executable) {
+	d.tools.mu.Lock()
+	configured := d.tools.ffmpegPath
+	d.tools.mu.Unlock()
+
+	candidates := userCandidates(configured, "ffmpeg")
+	for _, f := range bundledFiles {
+		if strings.TrimSuffix(f.name, ".exe") == "ffmpeg" {
+			dir, err := bundledDir()
+			if err != nil {
+				candidates = append(candidates, Executable{Path: f.name, Source: SourceBundled, Error: err.Error()})
+				continue
+			}
+			candidates = append(candidates, Executable{Path: filepath.Join(dir, f.name), Source: SourceBundled})
+		}
+	}
+
+	var checked []Executable
+	for _, c := range candidates {
+		exe := c
+		if exe.Error == "" {
+			exe = d.probe(ctx, c, "-version")
+		}
+		checked = append(checked, exe)
+		if exe.Works {
+			return &exe, checked
+		}
+	}
+	return nil, checked
+}
+
+// userCandidates returns the configured executable, if any, and the one named name on PATH
+func userCandidates(configured, name string) []Executable {
+	var candidates []Executable
+	if configured != "" {
+		candidates = append(candidates, Executable{Path: configured, Source: SourceSetting})
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if path != configured {
+			candidates = append(candidates, Executable{Path: path, Source: SourcePath})
+		}
+	}
+	return candidates
+}
+
+// bundledSpotdl returns the embedded spotdl, extracting it if needed
+func (d *Downloader) bundledSpotdl(ctx context.Context, probe bool) Executable {
+	exe := Executable{Source: SourceBundled}
+	if len(bundledFiles) == 0 {
+		exe.Error = "no spotdl binary is bundled for this platform"
+		return exe
+	}
+	dir, err := bundledDir()
+	if err != nil {
+		exe.Error = err.Error()
+		return exe
+	}
+	exe.Path = filepath.Join(dir, bundledFiles[0].name)
+	if !probe {
+		exe.Works = true
+		return exe
+	}
+	return d.probe(ctx, exe, "--version")
+}
+
+// probe runs exe with versionArg to check that it works and read its
+// version. Results are cached until the file changes.
+func (d *Downloader) probe(ctx context.Context, exe Executable, versionArg string) Executable {
+	info, err := os.Stat(exe.Path)
+	if err != nil {
+		exe.Error = err.Error()
+		return exe
+	}
+	if info.IsDir() {
+		exe.Error = fmt.Sprintf("%s is a directory", exe.Path)
+		return exe
+	}
+
+	d.tools.mu.Lock()
+	cached, ok := d.tools.probes[exe.Path]
+	d.tools.mu.Unlock()
+	if !ok || !cached.modTime.Equal(info.ModTime()) || cached.size != info.Size() {
+		cached = probeResult{modTime: info.ModTime(), size: info.Size()}
+		cached.version, cached.err = runVersion(ctx, exe.Path, versionArg)
+		if ctx.Err() == nil {
+			d.tools.mu.Lock()
+			if d.tools.probes == nil {
+				d.tools.probes = make(map[string]probeResult)
+			}
+			d.tools.probes[exe.Path] = cached
+			d.tools.mu.Unlock()
+		}
+	}
+
+	exe.Version = cached.version
+	exe.Works = cached.err == nil
+	if cached.err != nil {
+		exe.Error = cached.err.Error()
+	}
+	return exe
+}
+
+// runVersion runs path with versionArg and returns the version it prints
+func runVersion(ctx context.Context, path, versionArg string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, versionArg)
+	configureProcess(cmd)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s %s timed out", filepath.Base(path), versionArg)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s %s failed: %w", filepath.Base(path), versionArg, err)
+	}
+
+	first, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	first = strings.TrimSpace(first)
+	if m := ffmpegVersionPattern.FindStringSubmatch(first); m != nil {
+		return m[1], nil
+	}
+	if first == "" {
+		return "", errors.New("no version printed")
+	}
+	return first, nil
+}
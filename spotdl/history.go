@@ -0,0 +1,59 @@
+package spotdl
+
+import (
+	"log"
+	"time"
+)
+
+// Download statuses stored in the history
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Record describes one call to Download
+type Record struct {
+	Link         string // link as entered by the user
+	ResolvedLink string // canonical link passed to spotdl, empty if resolution failed
+	OutputPath   string
+	Format       string
+	Bitrate      string
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	Status       string
+	Error        string
+	Files        []string // files the download added to OutputPath
+}
+
+// History persists download records
+type History interface {
+	// Start records a download that just began and returns its ID
+	Start(r Record) (id int64, err error)
+	// Finish records the outcome of the download with the given ID
+	Finish(id int64, r Record) error
+}
+
+// startRecord records r as running, returning 0 when there is no history or it fails
+func (d *Downloader) startRecord(r Record) int64 {
+	if d.history == nil {
+		return 0
+	}
+	id, err := d.history.Start(r)
+	if err != nil {
+		log.Printf("Error recording download: %v", err)
+		return 0
+	}
+	return id
+}
+
+// finishRecord records the outcome of the download started with startRecord
+func (d *Downloader) finishRecord(id int64, r Record) {
+	if d.history == nil || id == 0 {
+		return
+	}
+	if err := d.history.Finish(id, r); err != nil {
+		log.Printf("Error recording download outcome: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package spotdl
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// runOutput is what a single run of spotdl knows about its output directory.
+// spotdl writes into the directory itself so that it skips the songs whose
+// file already exists, and the files present before the run are remembered
+// to tell them apart from the ones it added.
+type runOutput struct {
+	dir    string          // absolute output directory
+	ext    string          // extension of the files spotdl writes
+	before map[string]bool // names of the files already in dir
+	shared bool            // another run wrote to dir meanwhile, guarded by outputDirs.mu
+}
+
+// outputDirs tracks the runs writing to each output directory
+type outputDirs struct {
+	mu   sync.Mutex
+	runs map[string][]*runOutput
+}
+
+// startOutput lists the files already in output before a run writing files
+// of format into it
+func (d *Downloader) startOutput(output, format string) (*runOutput, error) {
+	if output == "" {
+		output = "."
+	}
+	dir, err := filepath.Abs(output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output directory %s: %w", output, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+
+	r := &runOutput{dir: dir, ext: "." + format, before: make(map[string]bool, len(entries))}
+	for _, entry := range entries {
+		r.before[entry.Name()] = true
+	}
+
+	d.outputs.mu.Lock()
+	defer d.outputs.mu.Unlock()
+	if d.outputs.runs == nil {
+		d.outputs.runs = make(map[string][]*runOutput)
+	}
+	others := d.outputs.runs[dir]
+	for _, other := range others {
+		other.shared = true
+		r.shared = true
+	}
+	d.outputs.runs[dir] = append(others, r)
+	return r, nil
+}
+
+// finishOutput returns the paths of the files the run added for the songs
+// keep accepts, a nil keep accepting none. With discard set the other added files are partial files of
+// a stopped run and are deleted, unless another run wrote to the directory
+// meanwhile and they may be its own.
+func (d *Downloader) finishOutput(r *runOutput, keep func(name string) bool, discard bool) []string {
+	d.outputs.mu.Lock()
+	shared := r.shared
+	runs := d.outputs.runs[r.dir]
+	for i, other := range runs {
+		if other == r {
+			runs = append(runs[:i:i], runs[i+1:]...)
+			break
+		}
+	}
+	if len(runs) == 0 {
+		delete(d.outputs.runs, r.dir)
+	} else {
+		d.outputs.runs[r.dir] = runs
+	}
+	d.outputs.mu.Unlock()
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Error listing downloaded files: %v", err)
+		}
+		return nil
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || r.before[name] || !strings.EqualFold(filepath.Ext(name), r.ext) {
+			continue
+		}
+		path := filepath.Join(r.dir, name)
+		switch {
+		case keep != nil && keep(name):
+			files = append(files, path)
+		case !discard:
+		case shared:
+			log.Printf("Left possibly partial file %s, another download wrote to %s too", path, r.dir)
+		default:
+			if err := os.Remove(path); err != nil {
+				log.Printf("Error removing partial file %s: %v", path, err)
+				continue
+			}
+			log.Printf("Removed partial file %s", path)
+		}
+	}
+	return files
+}
+
+// unsafeFilenameChars removes the characters spotdl leaves out of file names
+var unsafeFilenameChars = strings.NewReplacer(
+	`\`, "", "/", "", ":", "", "*", "", "?", "", `"`, "", "<", "", ">", "", "|", "",
+)
+
+// completedFile returns a keep function for finishOutput accepting the files
+// of the songs spotdl reported as downloaded. spotdl reports a song as
+// "Artist 1, Artist 2 - Title" but names its file after the first artist
+// only, so a file matches a song with the same title whose artists start
+// with the file's artist.
+func completedFile(completed map[string]bool) func(name string) bool {
+	songs := make([]string, 0, len(completed))
+	for song := range completed {
+		songs = append(songs, unsafeFilenameChars.Replace(song))
+	}
+	return func(name string) bool {
+		stem := strings.TrimSuffix(name, filepath.Ext(name))
+		artist, title, found := strings.Cut(stem, " - ")
+		for _, song := range songs {
+			if song == stem {
+				return true
+			}
+			if found && strings.HasPrefix(song, artist) && strings.HasSuffix(song, " - "+title) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package spotdl
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess runs cmd in its own process group and makes cancelling
+// it kill the whole group, so that the ffmpeg processes spawned by spotdl
+// don't outlive it
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package spotdl
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// configureProcess runs cmd in its own process group and makes cancelling
+// it kill the whole process tree, so that the ffmpeg processes spawned by
+// spotdl don't outlive it
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+	cmd.Cancel = func() error {
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+		kill.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+		if err := kill.Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
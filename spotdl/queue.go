@@ -0,0 +1,466 @@
+package spotdl
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Job statuses. Queued jobs start by priority, running jobs count against
+// the worker limit, and paused jobs wait until they are resumed.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobPaused    = "paused"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// DefaultWorkers is the number of downloads run in parallel unless configured otherwise
+const DefaultWorkers = 2
+
+// maxWorkers bounds the configurable number of parallel downloads
+const maxWorkers = 8
+
+// Job is a download in the queue
+type Job struct {
+	ID         int64     `json:"id"`
+	Link       string    `json:"link"`
+	OutputPath string    `json:"outputPath"`
+	Format     string    `json:"format"`
+	Bitrate    string    `json:"bitrate"`
+	Priority   int       `json:"priority"` // higher runs first
+	Status     string    `json:"status"`
+	Error      string    `json:"error"`
+	Attempts   int       `json:"attempts"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// JobStore persists the queue so that it survives restarts
+type JobStore interface {
+	// CreateJob stores a new job and returns its ID
+	CreateJob(job Job) (int64, error)
+	UpdateJob(job Job) error
+	DeleteJob(id int64) error
+	ListJobs() ([]Job, error)
+}
+
+// queue runs jobs in priority order, at most workers at a time
+type queue struct {
+	d       *Downloader
+	mu      sync.Mutex
+	store   JobStore
+	jobs    map[int64]*Job
+	workers int
+	running int
+	started bool
+	closed  bool
+	nextID  int64 // IDs of jobs created without a store
+
+	cancels map[int64]context.CancelFunc // stops the running jobs
+	stopAs  map[int64]string             // status of a running job once it stopped
+
+	done      map[int64]chan struct{} // closed once a job waited on is over
+	dirty     map[int64]bool          // jobs changed since they were last persisted
+	persistMu sync.Mutex              // keeps persisted states in order
+}
+
+func newQueue(d *Downloader) *queue {
+	return &queue{
+		d:       d,
+		jobs:    make(map[int64]*Job),
+		workers: DefaultWorkers,
+		cancels: make(map[int64]context.CancelFunc),
+		stopAs:  make(map[int64]string),
+		done:    make(map[int64]chan struct{}),
+		dirty:   make(map[int64]bool),
+	}
+}
+
+// setWorkers sets how many downloads run in parallel
+func (d *Downloader) setWorkers(n int) error {
+	if n < 1 || n > maxWorkers {
+		return fmt.Errorf("number of parallel downloads must be between 1 and %d", maxWorkers)
+	}
+	q := d.queue
+	defer q.persist()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.workers = n
+	q.schedule()
+	return nil
+}
+
+// start loads the persisted jobs and starts the queued ones. Jobs that were
+// running when the app stopped are queued again.
+func (q *queue) start() {
+	var jobs []Job
+	if store := q.jobStore(); store != nil {
+		var err error
+		if jobs, err = store.ListJobs(); err != nil {
+			log.Printf("Error loading download queue: %v", err)
+		}
+	}
+
+	defer q.persist()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range jobs {
+		job := job
+		q.jobs[job.ID] = &job
+		if job.Status == JobRunning {
+			q.setStatus(&job, JobQueued, "")
+		}
+	}
+	q.started = true
+	q.schedule()
+}
+
+// Enqueue adds a download to the queue and returns its job ID. Jobs with a
+// higher priority start first, jobs with the same priority in order.
+func (d *Downloader) Enqueue(link, outputPath, format, bitrate string, priority int) (int64, error) {
+	q := d.queue
+	now := time.Now()
+	job := &Job{
+		Link:       link,
+		OutputPath: outputPath,
+		Format:     format,
+		Bitrate:    bitrate,
+		Priority:   priority,
+		Status:     JobQueued,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	store := q.jobStore()
+	if store != nil {
+		id, err := store.CreateJob(*job)
+		if err != nil {
+			log.Printf("Error storing download job: %v", err)
+			return 0, err
+		}
+		job.ID = id
+	}
+
+	defer q.persist()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if store == nil {
+		q.nextID++
+		job.ID = q.nextID
+	}
+
+	q.jobs[job.ID] = job
+	q.emitUpdate(job)
+	q.schedule()
+	return job.ID, nil
+}
+
+// Pause keeps a queued job from starting until it is resumed. A running job
+// is stopped and starts over when resumed.
+func (d *Downloader) Pause(jobID int64) error {
+	if d.queue.stop(jobID, JobPaused) {
+		return nil
+	}
+	return d.queue.transition(jobID, JobPaused, JobQueued)
+}
+
+// Resume puts a paused job back in the queue
+func (d *Downloader) Resume(jobID int64) error {
+	return d.queue.transition(jobID, JobQueued, JobPaused)
+}
+
+// Cancel removes a job from the queue, stopping it if it is running
+func (d *Downloader) Cancel(jobID int64) error {
+	return d.CancelDownload(jobID)
+}
+
+// CancelDownload stops a running job, killing spotdl and removing the files
+// it was writing. Queued and paused jobs are cancelled before they start.
+func (d *Downloader) CancelDownload(jobID int64) error {
+	if d.queue.stop(jobID, JobCancelled) {
+		return nil
+	}
+	return d.queue.transition(jobID, JobCancelled, JobQueued, JobPaused)
+}
+
+// Retry queues a failed or cancelled job again
+func (d *Downloader) Retry(jobID int64) error {
+	return d.queue.transition(jobID, JobQueued, JobFailed, JobCancelled)
+}
+
+// RemoveJob removes a completed, failed or cancelled job from the queue
+func (d *Downloader) RemoveJob(jobID int64) error {
+	q := d.queue
+	defer q.persist()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return fmt.Errorf("download job %d not found", jobID)
+	}
+	if !job.finished() {
+		return fmt.Errorf("download job %d is %s", jobID, job.Status)
+	}
+	q.remove(jobID)
+	return nil
+}
+
+// ClearFinishedJobs removes every completed, failed and cancelled job from
+// the queue and returns how many were removed
+func (d *Downloader) ClearFinishedJobs() int {
+	q := d.queue
+	defer q.persist()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	removed := 0
+	for id, job := range q.jobs {
+		if job.finished() {
+			q.remove(id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// finished reports whether the job is over and will not run unless retried
+func (j *Job) finished() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// ListJobs returns every job of the queue, by priority then in order
+func (d *Downloader) ListJobs() []Job {
+	q := d.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	slices.SortFunc(jobs, compareJobs)
+	return jobs
+}
+
+// compareJobs orders jobs by descending priority, then by ID
+func compareJobs(a, b Job) int {
+	if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// stop cancels a running job, which gets status once spotdl exited. It
+// returns false if the job is not running.
+func (q *queue) stop(jobID int64, status string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cancel, ok := q.cancels[jobID]
+	if !ok {
+		return false
+	}
+	q.stopAs[jobID] = status
+	cancel()
+	return true
+}
+
+// close stops scheduling jobs. Jobs still running keep their status so that
+// they are queued again at the next startup.
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+}
+
+// transition moves a job to status if it currently has one of the from statuses
+func (q *queue) transition(jobID int64, status string, from ...string) error {
+	defer q.persist()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return fmt.Errorf("download job %d not found", jobID)
+	}
+	if !slices.Contains(from, job.Status) {
+		return fmt.Errorf("download job %d is %s", jobID, job.Status)
+	}
+	q.setStatus(job, status, "")
+	q.schedule()
+	return nil
+}
+
+// setStatus updates and emits the status of a job. The caller holds q.mu
+// and calls persist once it released it.
+func (q *queue) setStatus(job *Job, status, errMsg string) {
+	job.Status = status
+	job.Error = errMsg
+	job.UpdatedAt = time.Now()
+	q.dirty[job.ID] = true
+	q.emitUpdate(job)
+	if done, ok := q.done[job.ID]; ok && job.finished() {
+		close(done)
+		delete(q.done, job.ID)
+	}
+}
+
+// wait blocks until a job is over or ctx is done and returns the status of
+// the job, or "" if it is no longer in the queue
+func (q *queue) wait(ctx context.Context, jobID int64) string {
+	q.mu.Lock()
+	job, ok := q.jobs[jobID]
+	if !ok || job.finished() {
+		q.mu.Unlock()
+		return q.status(jobID)
+	}
+	done, ok := q.done[jobID]
+	if !ok {
+		done = make(chan struct{})
+		q.done[jobID] = done
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	return q.status(jobID)
+}
+
+// status returns the status of a job, or "" if it is not in the queue
+func (q *queue) status(jobID int64) string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if job, ok := q.jobs[jobID]; ok {
+		return job.Status
+	}
+	return ""
+}
+
+// remove deletes a job from the queue and emits its ID as
+// download_job_removed. The caller holds q.mu and calls persist once it
+// released it.
+func (q *queue) remove(jobID int64) {
+	delete(q.jobs, jobID)
+	q.dirty[jobID] = true
+	q.emit("download_job_removed", jobID)
+}
+
+// jobStore returns the store of the queue, if any
+func (q *queue) jobStore() JobStore {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.store
+}
+
+// persist stores the jobs changed since the last call and deletes the
+// removed ones. It is called without q.mu, so that a busy database never
+// blocks the queue, and stores the latest state of each job.
+func (q *queue) persist() {
+	q.persistMu.Lock()
+	defer q.persistMu.Unlock()
+
+	q.mu.Lock()
+	store := q.store
+	var jobs []Job
+	var removed []int64
+	for id := range q.dirty {
+		if job, ok := q.jobs[id]; ok {
+			jobs = append(jobs, *job)
+		} else {
+			removed = append(removed, id)
+		}
+	}
+	clear(q.dirty)
+	q.mu.Unlock()
+
+	if store == nil {
+		return
+	}
+	for _, job := range jobs {
+		if err := store.UpdateJob(job); err != nil {
+			log.Printf("Error storing download job %d: %v", job.ID, err)
+		}
+	}
+	for _, id := range removed {
+		if err := store.DeleteJob(id); err != nil {
+			log.Printf("Error deleting download job %d: %v", id, err)
+		}
+	}
+}
+
+// schedule starts queued jobs while workers are free. The caller holds q.mu.
+func (q *queue) schedule() {
+	if !q.started || q.closed {
+		return
+	}
+	for q.running < q.workers {
+		var next *Job
+		for _, job := range q.jobs {
+			if job.Status == JobQueued && (next == nil || compareJobs(*job, *next) < 0) {
+				next = job
+			}
+		}
+		if next == nil {
+			return
+		}
+
+		next.Attempts++
+		q.setStatus(next, JobRunning, "")
+		q.running++
+		ctx, cancel := context.WithCancel(q.d.baseContext())
+		q.cancels[next.ID] = cancel
+		go q.run(ctx, *next)
+	}
+}
+
+// run downloads a job and records its outcome
+func (q *queue) run(ctx context.Context, job Job) {
+	err := q.d.execute(ctx, job.ID, job.Link, job.OutputPath, job.Format, job.Bitrate)
+
+	defer q.persist()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.running--
+	q.cancels[job.ID]()
+	delete(q.cancels, job.ID)
+	status, stopped := q.stopAs[job.ID]
+	delete(q.stopAs, job.ID)
+
+	if current, ok := q.jobs[job.ID]; ok && !q.closed {
+		if stopped {
+			q.setStatus(current, status, "")
+		} else if err != nil {
+			q.setStatus(current, JobFailed, err.Error())
+		} else {
+			q.setStatus(current, JobCompleted, "")
+		}
+	}
+	q.schedule()
+}
+
+// emitUpdate emits the state of a job as download_job_update
+func (q *queue) emitUpdate(job *Job) {
+	q.emit("download_job_update", *job)
+}
+
+// emit sends an event to the frontend once it is running
+func (q *queue) emit(event string, payload any) {
+	if q.d.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(q.d.ctx, event, payload)
+}
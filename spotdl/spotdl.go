@@ -3,17 +3,14 @@ package spotdl
 
 import (
 	"context"
-	"embed"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
+	"strings"
 	"sync"
-
-	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"time"
 )
 
 const (
@@ -21,19 +18,102 @@ const (
 	filenameFormat = "{artist} - {title}.{output-ext}"
 )
 
+// shutdownTimeout bounds how long shutdown waits for cancelled downloads to exit
+const shutdownTimeout = 10 * time.Second
+
+// ErrCancelled is returned by downloads stopped before spotdl finished
+var ErrCancelled = errors.New("download cancelled")
+
+// LinkResolver turns any Spotify link pasted by the user into its canonical URL
+type LinkResolver func(ctx context.Context, link string) (string, error)
+
 // Downloader handles downloading of tracks from Spotify
 type Downloader struct {
-	ctx context.Context
+	ctx         context.Context
+	runCtx      context.Context    // parent of every download, cancelled by shutdown
+	stopAll     context.CancelFunc // cancels runCtx
+	running     sync.WaitGroup
+	resolveLink LinkResolver
+	history     History
+	queue       *queue
+	tools       executables
+	outputs     outputDirs
+}
+
+// Options are the dependencies of a Downloader. Wails binds every exported
+// method of the Downloader, so they are passed to NewDownloader instead of
+// being set through methods the frontend could call.
+type Options struct {
+	JobStore     JobStore     // persists the queue, nil keeps it in memory
+	History      History      // records the downloads, nil records nothing
+	LinkResolver LinkResolver // canonicalizes links, nil passes them to spotdl as is
+}
+
+// Controller changes the settings of a Downloader. It is kept apart from the
+// bound Downloader so that only the app can use it.
+type Controller struct {
+	d *Downloader
 }
 
-// NewDownloader creates a new Downloader instance
-func NewDownloader() *Downloader {
-	return &Downloader{}
+// NewDownloader creates a new Downloader instance and its Controller
+func NewDownloader(opts Options) (*Downloader, Controller) {
+	d := &Downloader{resolveLink: opts.LinkResolver, history: opts.History}
+	d.queue = newQueue(d)
+	d.queue.store = opts.JobStore
+	return d, Controller{d: d}
 }
 
-// Startup is called when the application starts
+// SetWorkers sets how many downloads run in parallel
+func (c Controller) SetWorkers(n int) error {
+	return c.d.setWorkers(n)
+}
+
+// SetExecutablePaths sets the spotdl and ffmpeg executables to prefer over
+// the ones on PATH and the bundled ones. Empty paths are ignored.
+func (c Controller) SetExecutablePaths(spotdlPath, ffmpegPath string) {
+	c.d.setExecutablePaths(spotdlPath, ffmpegPath)
+}
+
+// Shutdown cancels every running download and waits for spotdl to exit. No
+// job starts afterwards, so it is only called when the app quits.
+func (c Controller) Shutdown() {
+	c.d.shutdown()
+}
+
+// Startup is called when the application starts. It resumes the jobs left
+// unfinished by the previous run.
 func (d *Downloader) Startup(ctx context.Context) {
 	d.ctx = ctx
+	d.runCtx, d.stopAll = context.WithCancel(ctx)
+	d.queue.start()
+}
+
+// shutdown cancels every running download and waits for spotdl to exit.
+// Queued and interrupted jobs resume at the next startup.
+func (d *Downloader) shutdown() {
+	d.queue.close()
+	if d.stopAll != nil {
+		d.stopAll()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		log.Println("Timed out waiting for downloads to stop")
+	}
+}
+
+// baseContext returns the context downloads derive from
+func (d *Downloader) baseContext() context.Context {
+	if d.runCtx == nil {
+		return context.Background()
+	}
+	return d.runCtx
 }
 
 // Download downloads a track from the provided Spotify link
@@ -44,31 +124,93 @@ func (d *Downloader) Startup(ctx context.Context) {
 // - bitrate: quality of the output (128k, 320k, etc.)
 // - songsToDelete: optional list of songs to delete after download
 // Returns: boolean indicating whether the download was successful
+//
+// The download runs as a job of the queue, so its progress is reported
+// through download_event and it can be paused or cancelled by job ID.
+// Download returns once the job is over.
 func (d *Downloader) Download(link, outputPath, format, bitrate string, songsToDelete []string) bool {
-	// Extract the spotdl binary to a temporary location
-	tmpDir, err := os.MkdirTemp("", "spotdl")
+	jobID, err := d.Enqueue(link, outputPath, format, bitrate, 0)
 	if err != nil {
-		d.emitErrorEvent(fmt.Sprintf("failed to create temp directory: %v", err))
 		return false
 	}
-	defer os.RemoveAll(tmpDir)
+	return d.queue.wait(d.baseContext(), jobID) == JobCompleted
+}
 
-	// Determine file paths based on OS
-	var spotdlPath string
-	var ffmpegPath string
-	isWindows := runtime.GOOS == "windows"
+// execute downloads link, recording the run in the history and emitting
+// its progress as events for jobID. Cancelling ctx stops spotdl and discards
+// the files it was writing.
+func (d *Downloader) execute(ctx context.Context, jobID int64, link, outputPath, format, bitrate string) error {
+	d.running.Add(1)
+	defer d.running.Done()
 
-	if isWindows {
-		if success := extractWindowsBinaries(d, tmpDir); !success {
-			return false
-		}
-		spotdlPath = filepath.Join(tmpDir, "spotdl.exe")
-		ffmpegPath = filepath.Join(tmpDir, "ffmpeg.exe")
+	record := Record{
+		Link:       link,
+		OutputPath: outputPath,
+		Format:     format,
+		Bitrate:    bitrate,
+		StartedAt:  time.Now(),
+		Status:     StatusRunning,
+	}
+	id := d.startRecord(record)
+
+	p := newProgress(d, jobID)
+	p.emit(Event{Type: EventJobStarted, Link: link})
+	err := d.download(ctx, &record, p)
+
+	record.FinishedAt = time.Now()
+	if errors.Is(err, ErrCancelled) {
+		record.Status = StatusCancelled
+		record.Error = err.Error()
+	} else if err != nil {
+		record.Status = StatusFailed
+		record.Error = err.Error()
 	} else {
-		if success := extractLinuxBinaries(d, tmpDir); !success {
-			return false
+		record.Status = StatusCompleted
+	}
+	d.finishRecord(id, record)
+	p.finish(record.Status, record.Error)
+
+	return err
+}
+
+// download runs spotdl for record, reporting its output and any error to
+// p. The files of the songs it downloaded are stored in record.Files.
+func (d *Downloader) download(ctx context.Context, record *Record, p *progress) error {
+	link := record.Link
+	outputPath, format, bitrate := record.OutputPath, record.Format, record.Bitrate
+
+	// Normalize share links, URIs and short links to the URL spotdl expects
+	if d.resolveLink != nil {
+		canonical, err := d.resolveLink(ctx, link)
+		if ctx.Err() != nil {
+			return ErrCancelled
 		}
-		spotdlPath = filepath.Join(tmpDir, "spotdl")
+		if err != nil {
+			return d.fail(p, fmt.Sprintf("invalid link: %v", err))
+		}
+		link = canonical
+	}
+	record.ResolvedLink = link
+
+	// Prefer the user's spotdl and ffmpeg, falling back to the embedded ones
+	spotdl, candidates := d.findSpotdl(ctx, false)
+	if ctx.Err() != nil {
+		return ErrCancelled
+	}
+	if spotdl == nil {
+		var problems []string
+		for _, c := range candidates {
+			problems = append(problems, fmt.Sprintf("%s (%s): %s", c.Path, c.Source, c.Error))
+		}
+		return d.fail(p, fmt.Sprintf("no working spotdl found: %s", strings.Join(problems, "; ")))
+	}
+	ffmpeg, _ := d.findFFmpeg(ctx)
+
+	// Remember what the output path holds, so that only the files this run
+	// added are recorded or discarded
+	out, err := d.startOutput(outputPath, format)
+	if err != nil {
+		return d.fail(p, err.Error())
 	}
 
 	// Prepare arguments
@@ -76,41 +218,41 @@ func (d *Downloader) Download(link, outputPath, format, bitrate string, songsToD
 		link,
 		"--bitrate", bitrate,
 		"--format", format,
-		"--output",
-	}
-
-	outputFilePath := filenameFormat
-	if outputPath != "" {
-		outputFilePath = filepath.Join(outputPath, filenameFormat)
+		"--output", filepath.Join(out.dir, filenameFormat),
+		"--overwrite", "skip",
 	}
-	args = append(args, outputFilePath)
 
-	// Add ffmpeg path argument for Windows
-	if isWindows && ffmpegPath != "" {
-		args = append(args, "--ffmpeg", ffmpegPath)
+	// Without it spotdl looks for ffmpeg itself
+	if ffmpeg != nil {
+		args = append(args, "--ffmpeg", ffmpeg.Path)
 	}
 
-	// Execute spotdl
-	cmd := exec.Command(spotdlPath, args...)
+	// Execute spotdl, killing it and its children if ctx is cancelled
+	cmd := exec.CommandContext(ctx, spotdl.Path, args...)
+	configureProcess(cmd)
+	cmd.WaitDelay = 5 * time.Second
 
 	// Set up stdout and stderr pipes
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
-		d.emitErrorEvent(fmt.Sprintf("failed to create stdout pipe: %v", err))
-		return false
+		d.finishOutput(out, nil, false)
+		return d.fail(p, fmt.Sprintf("failed to create stdout pipe: %v", err))
 	}
 
 	stderrPipe, err := cmd.StderrPipe()
 	if err != nil {
-		d.emitErrorEvent(fmt.Sprintf("failed to create stderr pipe: %v", err))
-		return false
+		d.finishOutput(out, nil, false)
+		return d.fail(p, fmt.Sprintf("failed to create stderr pipe: %v", err))
 	}
 
 	// Start the command
-	wailsruntime.EventsEmit(d.ctx, "update_in_download", "Downloading")
+	if ctx.Err() != nil {
+		d.finishOutput(out, nil, false)
+		return ErrCancelled
+	}
 	if err := cmd.Start(); err != nil {
-		d.emitErrorEvent(fmt.Sprintf("failed to start command: %v", err))
-		return false
+		d.finishOutput(out, nil, false)
+		return d.fail(p, fmt.Sprintf("failed to start command: %v", err))
 	}
 
 	// Create a wait group to wait for the goroutines to finish
@@ -118,61 +260,30 @@ func (d *Downloader) Download(link, outputPath, format, bitrate string, songsToD
 	wg.Add(2)
 
 	// Process stdout
-	go d.pipeReader(&wg, stdoutPipe)
+	go d.pipeReader(&wg, stdoutPipe, p)
 
 	// Process stderr
-	go d.pipeReader(&wg, stderrPipe)
+	go d.pipeReader(&wg, stderrPipe, p)
 
-	// Wait for the command to finish
-	err = cmd.Wait()
+	// Read the output to the end before waiting for the command, Wait closes
+	// the pipes and would drop the last lines
 	wg.Wait()
+	err = cmd.Wait()
 
-	if err != nil {
-		d.emitErrorEvent(fmt.Sprintf("command execution failed: %v", err))
-		return false
+	// Keep the songs spotdl finished, even when it was stopped
+	record.Files = d.finishOutput(out, completedFile(p.completed()), ctx.Err() != nil)
+	if ctx.Err() != nil {
+		return ErrCancelled
 	}
-
-	wailsruntime.EventsEmit(d.ctx, "update_in_download", "Done")
-	return true
-}
-
-// extractBinary extracts a binary from the embedded FS to the target path
-func (d *Downloader) extractBinary(embeddedFS embed.FS, embeddedPath, targetPath string) error {
-	binData, err := embeddedFS.ReadFile(embeddedPath)
 	if err != nil {
-		d.emitErrorEvent(fmt.Sprintf("failed to read embedded binary %s: %v", embeddedPath, err))
-		return fmt.Errorf("failed to read embedded binary %s: %w", embeddedPath, err)
-	}
-
-	// Write the binary to the temp location
-	if err := os.WriteFile(targetPath, binData, 0755); err != nil {
-		d.emitErrorEvent(fmt.Sprintf("failed to write binary to temp location: %v", err))
-		return fmt.Errorf("failed to write binary to temp location: %w", err)
+		return d.fail(p, fmt.Sprintf("command execution failed: %v", err))
 	}
-
 	return nil
 }
 
-// pipeReader reads from a pipe and emits events with the content
-func (d *Downloader) pipeReader(wg *sync.WaitGroup, pipe io.ReadCloser) {
-	defer wg.Done()
-
-	buf := make([]byte, 1024)
-	for {
-		n, err := pipe.Read(buf)
-		if n > 0 {
-			output := string(buf[:n])
-			log.Print(output)
-			wailsruntime.EventsEmit(d.ctx, "update_in_download", output)
-		}
-		if err != nil {
-			break
-		}
-	}
-}
-
-// emitErrorEvent emits a fatal error event and a done event
-func (d *Downloader) emitErrorEvent(errMsg string) {
-	wailsruntime.EventsEmit(d.ctx, "update_in_download", fmt.Sprintf("fatal_error: %s", errMsg))
-	wailsruntime.EventsEmit(d.ctx, "update_in_download", "Done")
+// fail logs errMsg as the fatal error of the download and returns it. It
+// reaches the frontend as the reason of the job_finished event.
+func (d *Downloader) fail(p *progress, errMsg string) error {
+	log.Printf("Download of job %d failed: %s", p.jobID, errMsg)
+	return errors.New(errMsg)
 }